@@ -0,0 +1,198 @@
+package exchange
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+var ErrInvalidRate = errors.New("invalid exchange rate")
+var ErrUnknownFormat = errors.New("unknown rates file format")
+var ErrOverflow = errors.New("converted amount overflows")
+
+// Provider source of exchange rates. Rate returns how many units of to
+// currency one unit of from currency costs.
+type Provider interface {
+	Rate(from types.Currency, to types.Currency) (*big.Rat, error)
+}
+
+// Rounding rounding mode used when converted amount has fractional minor units
+type Rounding int
+
+// Supported rounding modes, RoundDown truncates toward zero and RoundUp rounds
+// away from zero
+const (
+	RoundHalfUp Rounding = iota
+	RoundHalfEven
+	RoundDown
+	RoundUp
+)
+
+type pair struct {
+	from types.Currency
+	to   types.Currency
+}
+
+// FileProvider provider with rates loaded from a local file
+type FileProvider struct {
+	rates map[pair]*big.Rat
+}
+
+type fileRate struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Rate string `json:"rate"`
+}
+
+// LoadFile loads rates from a .csv (from,to,rate per line) or .json (array of
+// {"from","to","rate"} objects) file.
+func LoadFile(path string) (*FileProvider, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ReadCSV(file)
+	case ".json":
+		return ReadJSON(file)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// ReadCSV reads rates in from,to,rate format
+func ReadCSV(reader io.Reader) (*FileProvider, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = 3
+	csvReader.TrimLeadingSpace = true
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	rates := []fileRate{}
+	for _, record := range records {
+		rates = append(rates, fileRate{From: record[0], To: record[1], Rate: record[2]})
+	}
+	return newFileProvider(rates)
+}
+
+// ReadJSON reads rates as array of {"from","to","rate"} objects
+func ReadJSON(reader io.Reader) (*FileProvider, error) {
+	rates := []fileRate{}
+	err := json.NewDecoder(reader).Decode(&rates)
+	if err != nil {
+		return nil, err
+	}
+	return newFileProvider(rates)
+}
+
+func newFileProvider(rates []fileRate) (*FileProvider, error) {
+	provider := &FileProvider{rates: map[pair]*big.Rat{}}
+	for _, rate := range rates {
+		value, ok := new(big.Rat).SetString(strings.TrimSpace(rate.Rate))
+		if !ok || value.Sign() <= 0 {
+			return nil, ErrInvalidRate
+		}
+		from := types.Currency(strings.ToUpper(strings.TrimSpace(rate.From)))
+		to := types.Currency(strings.ToUpper(strings.TrimSpace(rate.To)))
+		provider.rates[pair{from: from, to: to}] = value
+	}
+	return provider, nil
+}
+
+// Rate returns the direct rate, or the inverse of the reverse rate
+func (p *FileProvider) Rate(from types.Currency, to types.Currency) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+	if rate, ok := p.rates[pair{from: from, to: to}]; ok {
+		return new(big.Rat).Set(rate), nil
+	}
+	if rate, ok := p.rates[pair{from: to, to: from}]; ok {
+		return new(big.Rat).Inv(rate), nil
+	}
+	return nil, ErrRateNotFound
+}
+
+// ApplySpread worsens the rate for the customer by spread basis points
+func ApplySpread(rate *big.Rat, spread int64) *big.Rat {
+	markup := big.NewRat(10_000+spread, 10_000)
+	return new(big.Rat).Mul(rate, markup)
+}
+
+// Convert converts amount to currency using rate, rounding the result to
+// whole minor units of the target currency.
+func Convert(amount types.Amount, to types.Currency, rate *big.Rat, rounding Rounding) (types.Amount, error) {
+	value := new(big.Rat).SetInt64(int64(amount.Value))
+	value.Mul(value, rate)
+
+	exp := to.Exponent() - amount.Currency.Exponent()
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(exp))), nil)
+	if exp >= 0 {
+		value.Mul(value, new(big.Rat).SetInt(scale))
+	} else {
+		value.Quo(value, new(big.Rat).SetInt(scale))
+	}
+
+	result := round(value, rounding)
+	if !result.IsInt64() {
+		return types.Amount{}, ErrOverflow
+	}
+	return types.Amount{Value: types.Money(result.Int64()), Currency: to}, nil
+}
+
+// FormatRate formats rate as decimal without trailing zeros
+func FormatRate(rate *big.Rat) string {
+	str := rate.FloatString(8)
+	str = strings.TrimRight(str, "0")
+	return strings.TrimSuffix(str, ".")
+}
+
+func round(value *big.Rat, rounding Rounding) *big.Int {
+	quo, rem := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if rem.Sign() == 0 {
+		return quo
+	}
+
+	sign := int64(value.Sign())
+	away := new(big.Int).Add(quo, big.NewInt(sign))
+
+	// compare doubled remainder with denominator to find the nearest integer
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+	half := twice.Cmp(value.Denom())
+
+	switch rounding {
+	case RoundDown:
+		return quo
+	case RoundUp:
+		return away
+	case RoundHalfEven:
+		if half > 0 || (half == 0 && quo.Bit(0) == 1) {
+			return away
+		}
+		return quo
+	}
+	if half >= 0 {
+		return away
+	}
+	return quo
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
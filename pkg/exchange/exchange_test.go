@@ -0,0 +1,134 @@
+package exchange
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func TestReadCSV(t *testing.T) {
+	provider, err := ReadCSV(strings.NewReader("USD,TJS,11.30\nusd, rub, 74.5\n"))
+	if err != nil {
+		t.Errorf("ReadCSV(): error = %v", err)
+		return
+	}
+
+	rate, err := provider.Rate(types.CurrencyUSD, types.CurrencyRUB)
+	if err != nil {
+		t.Errorf("Rate(): error = %v", err)
+		return
+	}
+	if rate.Cmp(big.NewRat(745, 10)) != 0 {
+		t.Errorf("Rate(): wrong rate = %v", rate)
+		return
+	}
+
+	rate, err = provider.Rate(types.CurrencyTJS, types.CurrencyUSD)
+	if err != nil {
+		t.Errorf("Rate(): error = %v", err)
+		return
+	}
+	if rate.Cmp(big.NewRat(10, 113)) != 0 {
+		t.Errorf("Rate(): wrong inverse rate = %v", rate)
+		return
+	}
+
+	_, err = provider.Rate(types.CurrencyEUR, types.CurrencyUSD)
+	if err != ErrRateNotFound {
+		t.Errorf("Rate(): must return ErrRateNotFound, returned = %v", err)
+		return
+	}
+}
+
+func TestLoadFile_json(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	err := os.WriteFile(path, []byte(`[{"from":"EUR","to":"TJS","rate":"12.5"}]`), 0666)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	provider, err := LoadFile(path)
+	if err != nil {
+		t.Errorf("LoadFile(): error = %v", err)
+		return
+	}
+
+	rate, err := provider.Rate(types.CurrencyEUR, types.CurrencyTJS)
+	if err != nil || rate.Cmp(big.NewRat(25, 2)) != 0 {
+		t.Errorf("Rate(): wrong rate = %v, error = %v", rate, err)
+		return
+	}
+}
+
+func TestLoadFile_fail(t *testing.T) {
+	_, err := ReadCSV(strings.NewReader("USD,TJS,-1\n"))
+	if err != ErrInvalidRate {
+		t.Errorf("ReadCSV(): must return ErrInvalidRate, returned = %v", err)
+		return
+	}
+
+	_, err = LoadFile("rates.xml")
+	if err == nil {
+		t.Errorf("LoadFile(): must return error, returned nil")
+		return
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		rounding Rounding
+		value    types.Money
+		want     types.Money
+	}{
+		{RoundHalfUp, 2, 3},
+		{RoundHalfUp, 3, 4},
+		{RoundHalfUp, 5, 6},
+		{RoundHalfEven, 2, 2},
+		{RoundHalfEven, 6, 8},
+		{RoundHalfEven, 5, 6},
+		{RoundDown, 3, 3},
+		{RoundUp, 5, 7},
+		{RoundUp, 4, 5},
+	}
+
+	// 1.25 makes .25, .5 and .75 fractions in the converted value
+	rate := big.NewRat(5, 4)
+	for _, test := range tests {
+		amount := types.Amount{Value: test.value, Currency: types.CurrencyUSD}
+		got, err := Convert(amount, types.CurrencyTJS, rate, test.rounding)
+		if err != nil {
+			t.Errorf("Convert(): error = %v", err)
+			continue
+		}
+		if got.Value != test.want || got.Currency != types.CurrencyTJS {
+			t.Errorf("Convert(%v, %v): got = %v, want = %v", test.value, test.rounding, got, test.want)
+		}
+	}
+}
+
+func TestConvert_exponent(t *testing.T) {
+	amount := types.Amount{Value: 1_000, Currency: types.CurrencyJPY}
+	got, err := Convert(amount, types.CurrencyUSD, big.NewRat(1, 100), RoundHalfUp)
+	if err != nil {
+		t.Errorf("Convert(): error = %v", err)
+		return
+	}
+
+	if got.Value != 10_00 {
+		t.Errorf("Convert(): got = %v, want = %v", got.Value, 10_00)
+		return
+	}
+}
+
+func TestApplySpread(t *testing.T) {
+	rate := ApplySpread(big.NewRat(10, 1), 150)
+	if FormatRate(rate) != "10.15" {
+		t.Errorf("ApplySpread(): wrong rate = %v", FormatRate(rate))
+		return
+	}
+}
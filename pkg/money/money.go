@@ -0,0 +1,262 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+var ErrOverflow = errors.New("money overflow")
+var ErrInvalidAmount = errors.New("invalid money amount")
+var ErrInvalidRatios = errors.New("invalid allocation ratios")
+
+// Locale separators used to parse and format amounts
+type Locale struct {
+	Decimal rune
+	Group   rune
+}
+
+// Predefined locales
+var (
+	LocaleEN = Locale{Decimal: '.', Group: ','}
+	LocaleRU = Locale{Decimal: ',', Group: ' '}
+	LocaleTJ = Locale{Decimal: ',', Group: ' '}
+)
+
+// Add returns a + b or ErrOverflow
+func Add(a types.Money, b types.Money) (types.Money, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, ErrOverflow
+	}
+	return a + b, nil
+}
+
+// Sub returns a - b or ErrOverflow
+func Sub(a types.Money, b types.Money) (types.Money, error) {
+	if (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b) {
+		return 0, ErrOverflow
+	}
+	return a - b, nil
+}
+
+// Mul returns a * n or ErrOverflow
+func Mul(a types.Money, n int64) (types.Money, error) {
+	if a == 0 || n == 0 {
+		return 0, nil
+	}
+	result := int64(a) * n
+	if result/n != int64(a) || (a == -1 && n == math.MinInt64) || (n == -1 && a == math.MinInt64) {
+		return 0, ErrOverflow
+	}
+	return types.Money(result), nil
+}
+
+// Sum adds all values or returns ErrOverflow
+func Sum(values ...types.Money) (types.Money, error) {
+	sum := types.Money(0)
+	for _, value := range values {
+		var err error
+		sum, err = Add(sum, value)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return sum, nil
+}
+
+// Percent returns basisPoints/10000 of amount rounded half away from zero
+func Percent(amount types.Money, basisPoints int64) (types.Money, error) {
+	negative := (amount < 0) != (basisPoints < 0)
+	if amount < 0 {
+		amount = -amount
+	}
+	if basisPoints < 0 {
+		basisPoints = -basisPoints
+	}
+	if amount < 0 || basisPoints < 0 {
+		return 0, ErrOverflow
+	}
+
+	high, err := Mul(amount/10_000, basisPoints)
+	if err != nil {
+		return 0, err
+	}
+	low, err := Mul(amount%10_000, basisPoints)
+	if err != nil {
+		return 0, err
+	}
+	result, err := Add(high, (low+5_000)/10_000)
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		result = -result
+	}
+	return result, nil
+}
+
+// Allocate splits total proportionally to ratios. Minor units left after
+// integer division are given one by one to the first parts, so the parts
+// always add up to total.
+func Allocate(total types.Money, ratios []int64) ([]types.Money, error) {
+	sumRatios := int64(0)
+	for _, ratio := range ratios {
+		if ratio < 0 || sumRatios > math.MaxInt64-ratio {
+			return nil, ErrInvalidRatios
+		}
+		sumRatios += ratio
+	}
+	if sumRatios == 0 {
+		return nil, ErrInvalidRatios
+	}
+
+	parts := make([]types.Money, len(ratios))
+	rest := total
+	for i, ratio := range ratios {
+		part, err := mulDiv(total, ratio, sumRatios)
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = part
+		rest -= part
+	}
+
+	step := types.Money(1)
+	if rest < 0 {
+		step = -1
+	}
+	for i := 0; rest != 0; i = (i + 1) % len(parts) {
+		if ratios[i] == 0 {
+			continue
+		}
+		parts[i] += step
+		rest -= step
+	}
+	return parts, nil
+}
+
+// Split splits total into n equal parts differing by at most one minor unit
+func Split(total types.Money, n int) ([]types.Money, error) {
+	if n <= 0 {
+		return nil, ErrInvalidRatios
+	}
+	ratios := make([]int64, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return Allocate(total, ratios)
+}
+
+// Parse parses amount like "1 000.50" or "1,000.50" in major units of the
+// currency. Spaces are always accepted as group separators.
+func Parse(str string, currency types.Currency, locale Locale) (types.Money, error) {
+	str = strings.TrimSpace(str)
+	str = strings.TrimSpace(strings.TrimSuffix(str, string(currency)))
+
+	negative := false
+	if strings.HasPrefix(str, "-") {
+		negative = true
+		str = str[1:]
+	}
+
+	integer := ""
+	fraction := ""
+	seenDecimal := false
+	for _, char := range str {
+		switch {
+		case char >= '0' && char <= '9':
+			if seenDecimal {
+				fraction += string(char)
+			} else {
+				integer += string(char)
+			}
+		case char == locale.Decimal && !seenDecimal:
+			seenDecimal = true
+		case char == locale.Group || char == ' ' || char == '\u00a0':
+			if seenDecimal {
+				return 0, ErrInvalidAmount
+			}
+		default:
+			return 0, ErrInvalidAmount
+		}
+	}
+
+	exp := currency.Exponent()
+	if integer == "" || len(fraction) > exp || (seenDecimal && fraction == "") {
+		return 0, ErrInvalidAmount
+	}
+	fraction += strings.Repeat("0", exp-len(fraction))
+
+	value := types.Money(0)
+	for _, char := range integer + fraction {
+		var err error
+		value, err = Mul(value, 10)
+		if err != nil {
+			return 0, err
+		}
+		value, err = Add(value, types.Money(char-'0'))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+// Format formats amount like "1,000.00 TJS"
+func Format(amount types.Money, currency types.Currency, locale Locale) string {
+	negative := amount < 0
+	digits := []byte{}
+	value := uint64(amount)
+	if negative {
+		value = uint64(-amount)
+	}
+	for value > 0 {
+		digits = append([]byte{byte('0' + value%10)}, digits...)
+		value /= 10
+	}
+
+	exp := currency.Exponent()
+	for len(digits) <= exp {
+		digits = append([]byte{'0'}, digits...)
+	}
+	integer := string(digits[:len(digits)-exp])
+	fraction := string(digits[len(digits)-exp:])
+
+	builder := strings.Builder{}
+	if negative {
+		builder.WriteByte('-')
+	}
+	for i, char := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			builder.WriteRune(locale.Group)
+		}
+		builder.WriteRune(char)
+	}
+	if exp > 0 {
+		builder.WriteRune(locale.Decimal)
+		builder.WriteString(fraction)
+	}
+	builder.WriteString(" " + string(currency))
+	return builder.String()
+}
+
+func mulDiv(value types.Money, mul int64, div int64) (types.Money, error) {
+	// split value to avoid overflow of value * mul when value is large
+	quo := int64(value) / div
+	rem := int64(value) % div
+	high, err := Mul(types.Money(quo), mul)
+	if err != nil {
+		return 0, err
+	}
+	low, err := Mul(types.Money(rem), mul)
+	if err != nil {
+		return 0, err
+	}
+	return Add(high, low/types.Money(div))
+}
@@ -0,0 +1,153 @@
+package money
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func TestAdd(t *testing.T) {
+	sum, err := Add(10_000_000_000_00, 1_000_00)
+	if err != nil || sum != 10_000_001_000_00 {
+		t.Errorf("Add(): got = %v, error = %v", sum, err)
+		return
+	}
+
+	_, err = Add(math.MaxInt64, 1)
+	if err != ErrOverflow {
+		t.Errorf("Add(): must return ErrOverflow, returned = %v", err)
+		return
+	}
+
+	_, err = Sub(math.MinInt64, 1)
+	if err != ErrOverflow {
+		t.Errorf("Sub(): must return ErrOverflow, returned = %v", err)
+		return
+	}
+
+	_, err = Mul(math.MaxInt64/2+1, 2)
+	if err != ErrOverflow {
+		t.Errorf("Mul(): must return ErrOverflow, returned = %v", err)
+		return
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		amount      types.Money
+		basisPoints int64
+		want        types.Money
+	}{
+		{1_000_00, 100, 10_00},
+		{1_50, 100, 2},
+		{1_49, 100, 1},
+		{-1_50, 100, -2},
+		{10_000_000_000_00, 250, 250_000_000_00},
+	}
+
+	for _, test := range tests {
+		got, err := Percent(test.amount, test.basisPoints)
+		if err != nil || got != test.want {
+			t.Errorf("Percent(%v, %v): got = %v, want = %v, error = %v", test.amount, test.basisPoints, got, test.want, err)
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	parts, err := Allocate(100, []int64{1, 1, 1})
+	if err != nil {
+		t.Errorf("Allocate(): error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(parts, []types.Money{34, 33, 33}) {
+		t.Errorf("Allocate(): wrong parts = %v", parts)
+		return
+	}
+
+	parts, err = Allocate(1_000_05, []int64{70, 30, 0})
+	if err != nil {
+		t.Errorf("Allocate(): error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(parts, []types.Money{700_04, 300_01, 0}) {
+		t.Errorf("Allocate(): wrong parts = %v", parts)
+		return
+	}
+
+	_, err = Allocate(100, []int64{0, 0})
+	if err != ErrInvalidRatios {
+		t.Errorf("Allocate(): must return ErrInvalidRatios, returned = %v", err)
+		return
+	}
+}
+
+func TestSplit(t *testing.T) {
+	parts, err := Split(-10, 3)
+	if err != nil {
+		t.Errorf("Split(): error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(parts, []types.Money{-4, -3, -3}) {
+		t.Errorf("Split(): wrong parts = %v", parts)
+		return
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		str      string
+		currency types.Currency
+		locale   Locale
+		want     types.Money
+	}{
+		{"1 000.50", types.CurrencyTJS, LocaleEN, 1_000_50},
+		{"1,000.5", types.CurrencyUSD, LocaleEN, 1_000_50},
+		{"1 000,50 TJS", types.CurrencyTJS, LocaleRU, 1_000_50},
+		{"-12", types.CurrencyRUB, LocaleRU, -12_00},
+		{"1,000", types.CurrencyJPY, LocaleEN, 1_000},
+	}
+
+	for _, test := range tests {
+		got, err := Parse(test.str, test.currency, test.locale)
+		if err != nil {
+			t.Errorf("Parse(%q): error = %v", test.str, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Parse(%q): got = %v, want = %v", test.str, got, test.want)
+		}
+	}
+}
+
+func TestParse_fail(t *testing.T) {
+	for _, str := range []string{"", "abc", "1.005", "1.", "1.0.0", "99999999999999999999"} {
+		_, err := Parse(str, types.CurrencyUSD, LocaleEN)
+		if err == nil {
+			t.Errorf("Parse(%q): must return error, returned nil", str)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		amount   types.Money
+		currency types.Currency
+		locale   Locale
+		want     string
+	}{
+		{1_000_00, types.CurrencyTJS, LocaleEN, "1,000.00 TJS"},
+		{1_234_567_89, types.CurrencyRUB, LocaleRU, "1 234 567,89 RUB"},
+		{-5, types.CurrencyUSD, LocaleEN, "-0.05 USD"},
+		{1_000, types.CurrencyJPY, LocaleEN, "1,000 JPY"},
+		{0, types.CurrencyKWD, LocaleEN, "0.000 KWD"},
+	}
+
+	for _, test := range tests {
+		got := Format(test.amount, test.currency, test.locale)
+		if got != test.want {
+			t.Errorf("Format(%v): got = %q, want = %q", test.amount, got, test.want)
+		}
+	}
+}
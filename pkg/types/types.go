@@ -1,11 +1,65 @@
 package types
 
+import "time"
+
 // Money amount of money in minimum currency units (cents, rubles, dirhams, etc.)
 type Money int64
 
+// Currency ISO 4217 currency code (TJS, USD, RUB, etc.)
+type Currency string
+
+// Supported currencies
+const (
+	CurrencyTJS Currency = "TJS"
+	CurrencyUSD Currency = "USD"
+	CurrencyEUR Currency = "EUR"
+	CurrencyRUB Currency = "RUB"
+	CurrencyJPY Currency = "JPY"
+	CurrencyKWD Currency = "KWD"
+)
+
+// DefaultCurrency currency of accounts registered without explicit currency
+const DefaultCurrency = CurrencyTJS
+
+var currencyExponents = map[Currency]int{
+	CurrencyTJS: 2,
+	CurrencyUSD: 2,
+	CurrencyEUR: 2,
+	CurrencyRUB: 2,
+	CurrencyJPY: 0,
+	CurrencyKWD: 3,
+}
+
+// Valid reports whether the currency is supported
+func (c Currency) Valid() bool {
+	_, ok := currencyExponents[c]
+	return ok
+}
+
+// Exponent number of minor unit digits (2 for cents, 0 for yen)
+func (c Currency) Exponent() int {
+	return currencyExponents[c]
+}
+
+// Amount money together with its currency
+type Amount struct {
+	Value    Money
+	Currency Currency
+}
+
 // Category the category in which the payment was made (cars, pharmacies, food, etc.)
 type PaymentCategory string
 
+// CategoryInfo payment category registry entry. MCC are four digit merchant
+// category codes, Names display names by language code like "en" or "ru".
+type CategoryInfo struct {
+	ID      PaymentCategory
+	Parent  PaymentCategory
+	MCC     []string
+	Aliases []string
+	Names   map[string]string
+}
+
 // Status payment status
 type PaymentStatus string
 
@@ -14,6 +68,8 @@ const (
 	PaymentStatusOK         PaymentStatus = "OK"
 	PaymentStatusFail       PaymentStatus = "FAIL"
 	PaymentStatusInProgress PaymentStatus = "INPROGRESS"
+	PaymentStatusPartRefund PaymentStatus = "PARTREFUND"
+	PaymentStatusRefunded   PaymentStatus = "REFUNDED"
 )
 
 // Payment payment information
@@ -21,24 +77,404 @@ type Payment struct {
 	ID        string
 	AccountID int64
 	Amount    Money
+	Currency  Currency
 	Category  PaymentCategory
 	Status    PaymentStatus
+	Refunded  Money
+	Fee       Money
+	Created   time.Time
+	Metadata  map[string]string
+	Tags      []string
+	Note      string
+
+	// set when the payment is addressed to a merchant and when it is settled
+	MerchantID   int64
+	SettlementID string
+
+	// set when the payment is a share of a split payment
+	SplitID string
+
+	// account credited with Fee
+	FeeAccountID int64
+
+	// set when the payment was converted from another currency
+	OriginalAmount   Money
+	OriginalCurrency Currency
+	ExchangeRate     string
+}
+
+// Refund partial or full return of payment amount
+type Refund struct {
+	ID        string
+	PaymentID string
+	Amount    Money
 }
 
 type Phone string
 
+// AccountStatus account lifecycle status
+type AccountStatus string
+
+// Predefined account statuses
+const (
+	AccountStatusActive AccountStatus = "ACTIVE"
+	AccountStatusFrozen AccountStatus = "FROZEN"
+	AccountStatusClosed AccountStatus = "CLOSED"
+)
+
+// Tier account verification (KYC) level
+type Tier string
+
+// Predefined verification tiers
+const (
+	TierAnonymous Tier = "ANONYMOUS"
+	TierBasic     Tier = "BASIC"
+	TierFull      Tier = "FULL"
+)
+
+// TierLimits balance and monthly outgoing turnover allowed for a tier, zero
+// means no limit
+type TierLimits struct {
+	MaxBalance      Money
+	MonthlyTurnover Money
+}
+
+// TierChange audit record of account tier change
+type TierChange struct {
+	AccountID int64
+	From      Tier
+	To        Tier
+	Reason    string
+	Changed   time.Time
+}
+
+// Account wallet account. Balance is the ledger balance, Held is the part of it
+// reserved by active holds.
 type Account struct {
-	ID      int64
-	Phone   Phone
-	Balance Money
+	ID       int64
+	Phone    Phone
+	Balance  Money
+	Held     Money
+	Currency Currency
+	Status   AccountStatus
+	Tier     Tier
+	Pocketed Money
+}
+
+// Available balance that can be spent right now, money held or set aside in
+// pockets is not available
+func (a *Account) Available() Money {
+	return a.Balance - a.Held - a.Pocketed
+}
+
+// Pocket named savings sub-balance of an account. Its money is part of the
+// account balance, RoundUp is the unit payments are rounded up to with the
+// spare change moved into the pocket, zero disables round-ups.
+type Pocket struct {
+	ID         string
+	AccountID  int64
+	Name       string
+	Balance    Money
+	Target     Money
+	TargetDate time.Time
+	RoundUp    Money
+}
+
+// Remaining amount left to reach the pocket target
+func (p *Pocket) Remaining() Money {
+	if p.Balance >= p.Target {
+		return 0
+	}
+	return p.Target - p.Balance
+}
+
+// HoldStatus authorization hold status
+type HoldStatus string
+
+// Predefined hold statuses
+const (
+	HoldStatusActive   HoldStatus = "ACTIVE"
+	HoldStatusCaptured HoldStatus = "CAPTURED"
+	HoldStatusVoided   HoldStatus = "VOIDED"
+	HoldStatusExpired  HoldStatus = "EXPIRED"
+)
+
+// Hold funds reserved on account until captured, voided or expired
+type Hold struct {
+	ID        string
+	AccountID int64
+	Amount    Money
+	Status    HoldStatus
+	PaymentID string
+	Created   time.Time
+	Expires   time.Time
 }
 
+// RequestStatus money request status
+type RequestStatus string
+
+// Predefined money request statuses
+const (
+	RequestStatusPending   RequestStatus = "PENDING"
+	RequestStatusAccepted  RequestStatus = "ACCEPTED"
+	RequestStatusDeclined  RequestStatus = "DECLINED"
+	RequestStatusCancelled RequestStatus = "CANCELLED"
+	RequestStatusExpired   RequestStatus = "EXPIRED"
+)
+
+// MoneyRequest request of FromAccountID to be paid Amount by ToAccountID
+type MoneyRequest struct {
+	ID            string
+	FromAccountID int64
+	ToAccountID   int64
+	Amount        Money
+	Note          string
+	Status        RequestStatus
+	TransferID    string
+	Created       time.Time
+	Expires       time.Time
+}
+
+// SpendingLimits limits on account spending, zero value means no limit.
+// Category limits are monthly.
+type SpendingLimits struct {
+	PerTransaction Money
+	Daily          Money
+	Monthly        Money
+	Categories     map[PaymentCategory]Money
+}
+
+// FeeKind how the fee amount is calculated
+type FeeKind string
+
+// Predefined fee kinds
+const (
+	FeeFlat    FeeKind = "FLAT"
+	FeePercent FeeKind = "PERCENT"
+	FeeTiered  FeeKind = "TIERED"
+)
+
+// FeeTier fee step used for payments starting from the amount
+type FeeTier struct {
+	From    Money
+	Flat    Money
+	Percent int64
+}
+
+// FeeRule commission charged on payments. Empty Category or Tier matches any,
+// Percent is in basis points (100 = 1%), zero Min and Max mean no cap.
+type FeeRule struct {
+	Category PaymentCategory
+	Tier     Tier
+	Kind     FeeKind
+	Flat     Money
+	Percent  int64
+	Tiers    []FeeTier
+	Min      Money
+	Max      Money
+}
+
+// CashbackRule reward for payments. Empty Category matches any, Percent is
+// cashback in basis points, Points are given for each whole currency unit.
+type CashbackRule struct {
+	Category      PaymentCategory
+	Percent       int64
+	Max           Money
+	PointsPerUnit int64
+}
+
+// LoyaltyEntry cashback and points accrued for a payment, reversals have
+// negative values
+type LoyaltyEntry struct {
+	ID        string
+	AccountID int64
+	PaymentID string
+	Cashback  Money
+	Points    int64
+	Created   time.Time
+}
+
+// Transfer money moved between two accounts of the wallet
+type Transfer struct {
+	ID            string
+	FromAccountID int64
+	ToAccountID   int64
+	Amount        Money
+	Created       time.Time
+}
+
+// RepeatOverrides changes applied when repeating a payment, zero values keep
+// the original
+type RepeatOverrides struct {
+	Amount   Money
+	Category PaymentCategory
+}
+
+// SplitKind how a split payment total is divided between accounts
+type SplitKind string
+
+// Split kinds, percent shares are given in basis points
+const (
+	SplitEqual   SplitKind = "EQUAL"
+	SplitPercent SplitKind = "PERCENT"
+	SplitExact   SplitKind = "EXACT"
+)
+
+// SplitShare part of a split payment paid by the account, Percent is used
+// by SplitPercent and Amount by SplitExact
+type SplitShare struct {
+	AccountID int64
+	Percent   int64
+	Amount    Money
+}
+
+// Split payment funded by several accounts, each share is a payment with
+// SplitID set
+type Split struct {
+	ID         string
+	Amount     Money
+	Category   PaymentCategory
+	Kind       SplitKind
+	Status     PaymentStatus
+	PaymentIDs []string
+	Created    time.Time
+}
+
+// BatchMode how a batch reacts to failed items
+type BatchMode string
+
+// Batch modes, in atomic mode one failed item rolls back the whole batch
+const (
+	BatchModeAtomic     BatchMode = "ATOMIC"
+	BatchModeBestEffort BatchMode = "BEST_EFFORT"
+)
+
+// BatchItem one payment of a batch
+type BatchItem struct {
+	AccountID int64
+	Amount    Money
+	Category  PaymentCategory
+}
+
+// Merchant receiver of payments, Balance is the amount owed to the merchant
+// and Commission basis points retained on settlement
+type Merchant struct {
+	ID         int64
+	Name       string
+	Category   PaymentCategory
+	Currency   Currency
+	Balance    Money
+	Commission int64
+}
+
+// Settlement payout to a merchant for its payments since previous settlement,
+// Refunded includes refunds and rejections of earlier settled payments
+type Settlement struct {
+	ID         string
+	BatchID    string
+	MerchantID int64
+	Payments   int
+	Gross      Money
+	Refunded   Money
+	Fee        Money
+	Net        Money
+	Currency   Currency
+}
+
+// PayoutBatch settlements made by one settlement run
+type PayoutBatch struct {
+	ID          string
+	Created     time.Time
+	Settlements []Settlement
+}
+
+// Favorite saved payment. A template favorite has a variable amount within
+// MinAmount and MaxAmount and fields filled on every payment.
 type Favorite struct {
 	ID        string
 	AccountID int64
 	Name      string
 	Amount    Money
 	Category  PaymentCategory
+	Position  int
+	MinAmount Money
+	MaxAmount Money
+	Fields    []TemplateField
+	// set when the favorite pays a merchant
+	MerchantID int64
+}
+
+// TemplateField value supplied when paying from a template, Pattern is an
+// optional regular expression the whole value must match
+type TemplateField struct {
+	Name     string
+	Required bool
+	Pattern  string
+}
+
+// IdempotencyRecord result of a money-moving call saved under the client key
+type IdempotencyRecord struct {
+	Key       string
+	Request   string
+	PaymentID string
+	Created   time.Time
+}
+
+// Recurrence how often a standing order runs
+type Recurrence string
+
+// Predefined recurrences
+const (
+	RecurrenceOnce    Recurrence = "ONCE"
+	RecurrenceDaily   Recurrence = "DAILY"
+	RecurrenceWeekly  Recurrence = "WEEKLY"
+	RecurrenceMonthly Recurrence = "MONTHLY"
+)
+
+// StandingOrder payment executed by schedule, either from a favorite or, for
+// queued payments, with its own amount and category. Day is the day of month
+// for monthly orders. Attempts and RetryAt show a pending retry of the last
+// failed run.
+type StandingOrder struct {
+	ID         string
+	FavoriteID string
+	AccountID  int64
+	Amount     Money
+	Category   PaymentCategory
+	Recurrence Recurrence
+	Day        int
+	NextRun    time.Time
+	Active     bool
+	Attempts   int
+	RetryAt    time.Time
+}
+
+// RetryPolicy retries of standing orders failed for lack of balance. Backoff
+// doubles after each attempt up to MaxBackoff.
+type RetryPolicy struct {
+	MaxAttempts       int
+	InitialBackoff    time.Duration
+	MaxBackoff        time.Duration
+	RetryAfterDeposit bool
+}
+
+// RunStatus result of a standing order run
+type RunStatus string
+
+// Predefined run statuses
+const (
+	RunStatusOK   RunStatus = "OK"
+	RunStatusFail RunStatus = "FAIL"
+)
+
+// OrderRun outcome of one standing order execution
+type OrderRun struct {
+	OrderID   string
+	At        time.Time
+	Attempt   int
+	Status    RunStatus
+	PaymentID string
+	Error     string
 }
 
 type Progress struct {
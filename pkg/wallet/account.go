@@ -0,0 +1,90 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+var ErrAccountFrozen = errors.New("account is frozen")
+var ErrAccountClosed = errors.New("account is closed")
+var ErrAccountHasBalance = errors.New("account balance is not zero")
+
+// Freeze blocks all spending and deposits on the account
+func (s *Service) Freeze(accountID int64) error {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	if account.Status == types.AccountStatusClosed {
+		return ErrAccountClosed
+	}
+	account.Status = types.AccountStatusFrozen
+	return nil
+}
+
+// Unfreeze makes a frozen account active again
+func (s *Service) Unfreeze(accountID int64) error {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	if account.Status == types.AccountStatusClosed {
+		return ErrAccountClosed
+	}
+	account.Status = types.AccountStatusActive
+	return nil
+}
+
+// Close closes an account with zero balance, no active holds and empty
+// pockets
+func (s *Service) Close(accountID int64) error {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	if account.Status == types.AccountStatusClosed {
+		return ErrAccountClosed
+	}
+	if account.Pocketed != 0 {
+		return ErrPocketNotEmpty
+	}
+	if account.Balance != 0 || account.Held != 0 {
+		return ErrAccountHasBalance
+	}
+	account.Status = types.AccountStatusClosed
+	return nil
+}
+
+// CloseWithPayout transfers the whole balance to another account and closes
+// the account
+func (s *Service) CloseWithPayout(accountID int64, payoutAccountID int64) error {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	if account.Pocketed != 0 {
+		return ErrPocketNotEmpty
+	}
+	if account.Balance > 0 {
+		_, err = s.Transfer(accountID, payoutAccountID, account.Balance)
+		if err != nil {
+			return err
+		}
+	}
+	return s.Close(accountID)
+}
+
+func checkAccountActive(account *types.Account) error {
+	switch account.Status {
+	case types.AccountStatusFrozen:
+		return ErrAccountFrozen
+	case types.AccountStatusClosed:
+		return ErrAccountClosed
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_Freeze_success(t *testing.T) {
+	s := newTestService()
+	account, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Freeze(account.ID)
+	if err != nil {
+		t.Errorf("Freeze(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 100, "auto")
+	if err != ErrAccountFrozen {
+		t.Errorf("Pay(): must return ErrAccountFrozen, returned = %v", err)
+		return
+	}
+
+	err = s.Deposit(account.ID, 100)
+	if err != ErrAccountFrozen {
+		t.Errorf("Deposit(): must return ErrAccountFrozen, returned = %v", err)
+		return
+	}
+
+	_, err = s.Repeat(payments[0].ID)
+	if err != ErrAccountFrozen {
+		t.Errorf("Repeat(): must return ErrAccountFrozen, returned = %v", err)
+		return
+	}
+
+	err = s.Unfreeze(account.ID)
+	if err != nil {
+		t.Errorf("Unfreeze(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 100, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+}
+
+func Test_Close_fail(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Close(account.ID)
+	if err != ErrAccountHasBalance {
+		t.Errorf("Close(): must return ErrAccountHasBalance, returned = %v", err)
+		return
+	}
+
+	if account.Status != types.AccountStatusActive {
+		t.Errorf("Close(): status changed, account = %v", account)
+		return
+	}
+}
+
+func Test_CloseWithPayout_success(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payout, err := s.RegisterAccount("992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.UpgradeTier(payout.ID, types.TierFull, "payout account")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	balance := account.Balance
+	err = s.CloseWithPayout(account.ID, payout.ID)
+	if err != nil {
+		t.Errorf("CloseWithPayout(): error = %v", err)
+		return
+	}
+
+	if account.Status != types.AccountStatusClosed || account.Balance != 0 || payout.Balance != balance {
+		t.Errorf("CloseWithPayout(): wrong result, account = %v, payout = %v", account, payout)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 100, "auto")
+	if err != ErrAccountClosed {
+		t.Errorf("Pay(): must return ErrAccountClosed, returned = %v", err)
+		return
+	}
+
+	err = s.Unfreeze(account.ID)
+	if err != ErrAccountClosed {
+		t.Errorf("Unfreeze(): must return ErrAccountClosed, returned = %v", err)
+		return
+	}
+}
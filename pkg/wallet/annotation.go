@@ -0,0 +1,116 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+var ErrInvalidTag = errors.New("invalid tag")
+var ErrInvalidMetadataKey = errors.New("invalid metadata key")
+
+// SetPaymentNote replaces the note of the payment, empty note removes it
+func (s *Service) SetPaymentNote(paymentID string, note string) error {
+	payment, err := s.FindPaymentByID(paymentID)
+	if err != nil {
+		return err
+	}
+
+	payment.Note = strings.TrimSpace(note)
+	return nil
+}
+
+// AddPaymentTag adds tag to the payment, tags are compared case-insensitively
+// and adding an existing tag does nothing
+func (s *Service) AddPaymentTag(paymentID string, tag string) error {
+	payment, err := s.FindPaymentByID(paymentID)
+	if err != nil {
+		return err
+	}
+
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return ErrInvalidTag
+	}
+	if hasTag(payment, tag) {
+		return nil
+	}
+
+	payment.Tags = append(append([]string{}, payment.Tags...), tag)
+	return nil
+}
+
+// RemovePaymentTag removes tag from the payment
+func (s *Service) RemovePaymentTag(paymentID string, tag string) error {
+	payment, err := s.FindPaymentByID(paymentID)
+	if err != nil {
+		return err
+	}
+
+	tags := []string{}
+	for _, existing := range payment.Tags {
+		if !strings.EqualFold(existing, strings.TrimSpace(tag)) {
+			tags = append(tags, existing)
+		}
+	}
+	if len(tags) == 0 {
+		tags = nil
+	}
+	payment.Tags = tags
+	return nil
+}
+
+// SetPaymentMetadata sets metadata value of the payment, empty value removes
+// the key
+func (s *Service) SetPaymentMetadata(paymentID string, key string, value string) error {
+	payment, err := s.FindPaymentByID(paymentID)
+	if err != nil {
+		return err
+	}
+
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return ErrInvalidMetadataKey
+	}
+
+	metadata := map[string]string{}
+	for k, v := range payment.Metadata {
+		metadata[k] = v
+	}
+	if value == "" {
+		delete(metadata, key)
+	} else {
+		metadata[key] = value
+	}
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+	payment.Metadata = metadata
+	return nil
+}
+
+// ExportAccountHistoryByTag returns payments of the account having tag
+func (s *Service) ExportAccountHistoryByTag(accountID int64, tag string) ([]types.Payment, error) {
+	history, err := s.ExportAccountHistory(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	tagged := []types.Payment{}
+	for _, payment := range history {
+		if hasTag(&payment, strings.TrimSpace(tag)) {
+			tagged = append(tagged, payment)
+		}
+	}
+	return tagged, nil
+}
+
+func hasTag(payment *types.Payment, tag string) bool {
+	for _, existing := range payment.Tags {
+		if strings.EqualFold(existing, tag) {
+			return true
+		}
+	}
+	return false
+}
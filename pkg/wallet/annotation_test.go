@@ -0,0 +1,249 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_PaymentAnnotations(t *testing.T) {
+	s := newTestService()
+	account, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	other, err := s.Pay(account.ID, 5_00, "mobile")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment := payments[0]
+	err = s.AddPaymentTag(payment.ID, " Business trip ")
+	if err != nil {
+		t.Errorf("AddPaymentTag(): error = %v", err)
+		return
+	}
+	err = s.AddPaymentTag(payment.ID, "business TRIP")
+	if err != nil {
+		t.Errorf("AddPaymentTag(): error = %v", err)
+		return
+	}
+	err = s.AddPaymentTag(other.ID, "personal")
+	if err != nil {
+		t.Errorf("AddPaymentTag(): error = %v", err)
+		return
+	}
+	err = s.AddPaymentTag(payment.ID, " ")
+	if err != ErrInvalidTag {
+		t.Errorf("AddPaymentTag(): must return ErrInvalidTag, returned = %v", err)
+		return
+	}
+
+	err = s.SetPaymentNote(payment.ID, "receipt #42")
+	if err != nil {
+		t.Errorf("SetPaymentNote(): error = %v", err)
+		return
+	}
+	err = s.SetPaymentMetadata(payment.ID, "receipt", "42")
+	if err != nil {
+		t.Errorf("SetPaymentMetadata(): error = %v", err)
+		return
+	}
+
+	history, err := s.ExportAccountHistoryByTag(account.ID, "business trip")
+	if err != nil {
+		t.Errorf("ExportAccountHistoryByTag(): error = %v", err)
+		return
+	}
+	if len(history) != 1 || history[0].ID != payment.ID || !reflect.DeepEqual(history[0].Tags, []string{"Business trip"}) || history[0].Note != "receipt #42" || history[0].Metadata["receipt"] != "42" {
+		t.Errorf("ExportAccountHistoryByTag(): history = %v", history)
+		return
+	}
+
+	err = s.RemovePaymentTag(payment.ID, "BUSINESS TRIP")
+	if err != nil {
+		t.Errorf("RemovePaymentTag(): error = %v", err)
+		return
+	}
+	err = s.SetPaymentMetadata(payment.ID, "receipt", "")
+	if err != nil {
+		t.Errorf("SetPaymentMetadata(): error = %v", err)
+		return
+	}
+	if payment.Tags != nil || payment.Metadata != nil {
+		t.Errorf("RemovePaymentTag(): payment = %v", payment)
+		return
+	}
+}
+
+func Test_Import_annotations(t *testing.T) {
+	s := newTestService()
+	_, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment := payments[0]
+	text := "a;b,c\nd%e&f=g"
+	err = s.AddPaymentTag(payment.ID, text)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.AddPaymentTag(payment.ID, "trip")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetPaymentNote(payment.ID, text)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetPaymentMetadata(payment.ID, text, text)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+
+	got, err := imported.FindPaymentByID(payment.ID)
+	if err != nil || !reflect.DeepEqual(got.Tags, payment.Tags) || got.Note != payment.Note || !reflect.DeepEqual(got.Metadata, payment.Metadata) {
+		t.Errorf("Import(): payment = %v, error = %v", got, err)
+		return
+	}
+
+	history := t.TempDir()
+	err = HistoryToFile([]types.Payment{*payment}, filepath.Join(history, "payments.dump"))
+	if err != nil {
+		t.Errorf("HistoryToFile(): error = %v", err)
+		return
+	}
+	fromHistory := newTestService()
+	err = fromHistory.Import(history)
+	if err != nil {
+		t.Errorf("Import(): history not in dump format, error = %v", err)
+		return
+	}
+	got, err = fromHistory.FindPaymentByID(payment.ID)
+	if err != nil || got.Currency != payment.Currency || got.Fee != payment.Fee || got.Note != payment.Note || !reflect.DeepEqual(got.Tags, payment.Tags) {
+		t.Errorf("Import(): payment from history = %v, error = %v", got, err)
+		return
+	}
+}
+
+func Test_Import_escapedCategories(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	category := types.PaymentCategory("a;b+c%")
+	payment, err := s.PayWithKey("key", account.ID, 10_00, category)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	favorite, err := s.FavoritePayment(payment.ID, "escaped")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	order, err := s.QueuePayment(account.ID, 10_00, category)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	merchant, err := s.RegisterMerchant("Shop", category, "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+
+	gotPayment, err := imported.FindPaymentByID(payment.ID)
+	if err != nil || gotPayment.Category != category {
+		t.Errorf("Import(): payment = %v, error = %v", gotPayment, err)
+		return
+	}
+	gotFavorite, err := imported.FindFavoriteByID(favorite.ID)
+	if err != nil || gotFavorite.Category != category {
+		t.Errorf("Import(): favorite = %v, error = %v", gotFavorite, err)
+		return
+	}
+	gotOrder, err := imported.FindOrderByID(order.ID)
+	if err != nil || gotOrder.Category != category {
+		t.Errorf("Import(): order = %v, error = %v", gotOrder, err)
+		return
+	}
+	gotMerchant, err := imported.FindMerchantByID(merchant.ID)
+	if err != nil || gotMerchant.Category != category {
+		t.Errorf("Import(): merchant = %v, error = %v", gotMerchant, err)
+		return
+	}
+
+	repeated, err := imported.PayWithKey("key", account.ID, 10_00, category)
+	if err != nil || repeated.ID != payment.ID {
+		t.Errorf("PayWithKey(): payment = %v, error = %v", repeated, err)
+		return
+	}
+}
+
+func Test_Import_legacyPayments(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "payments.dump"), []byte("p1;1;100;100%;INPROGRESS\np2;1;100;a+b;INPROGRESS"), 0666)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	s := newTestService()
+	err = s.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	first, err := s.FindPaymentByID("p1")
+	if err != nil || first.Category != "100%" {
+		t.Errorf("Import(): payment = %v, error = %v", first, err)
+		return
+	}
+	second, err := s.FindPaymentByID("p2")
+	if err != nil || second.Category != "a+b" {
+		t.Errorf("Import(): payment = %v, error = %v", second, err)
+		return
+	}
+}
@@ -0,0 +1,277 @@
+package wallet
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/fm2901/wallet/pkg/money"
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+var ErrEmptyBatch = errors.New("batch is empty")
+var ErrInvalidBatchMode = errors.New("invalid batch mode")
+var ErrBatchFailed = errors.New("batch failed")
+
+// BatchResult result of one batch item. In atomic mode items that were paid
+// before the batch failed keep their rejected Payment and get ErrBatchFailed.
+type BatchResult struct {
+	Index   int
+	Item    types.BatchItem
+	Payment *types.Payment
+	Err     error
+}
+
+// PayBatch validates all items and pays the valid ones. Items are grouped by
+// account and the groups are handled by up to goroutines workers, items of
+// one account keep their order. Validation of different accounts runs in
+// parallel, while the payments themselves are made one at a time under a
+// lock, because Pay updates state shared by all accounts.
+//
+// Validation runs the checks of Pay for each item together with the items of
+// the same account before it. In atomic mode nothing is paid when any item is
+// invalid, and if a payment still fails the paid items are rejected and their
+// round-ups are moved back from the pockets. ErrBatchFailed is returned in
+// both cases.
+func (s *Service) PayBatch(items []types.BatchItem, mode types.BatchMode, goroutines int) ([]BatchResult, error) {
+	if len(items) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if mode != types.BatchModeAtomic && mode != types.BatchModeBestEffort {
+		return nil, ErrInvalidBatchMode
+	}
+	if goroutines < 1 {
+		goroutines = 1
+	}
+
+	results := make([]BatchResult, len(items))
+	for i, item := range items {
+		results[i] = BatchResult{Index: i, Item: item}
+	}
+
+	// items are grouped by account so one account is never handled concurrently
+	groups := [][]int{}
+	groupByAccount := map[int64]int{}
+	for i, item := range items {
+		group, ok := groupByAccount[item.AccountID]
+		if !ok {
+			group = len(groups)
+			groupByAccount[item.AccountID] = group
+			groups = append(groups, []int{})
+		}
+		groups[group] = append(groups[group], i)
+	}
+
+	valid := s.validateBatch(results, groups, goroutines)
+	if !valid && mode == types.BatchModeAtomic {
+		return results, ErrBatchFailed
+	}
+
+	roundUps := make([]batchRoundUp, len(results))
+	failed := false
+	mu := sync.Mutex{}
+	runBatchGroups(groups, goroutines, func(group []int) {
+		for _, index := range group {
+			if results[index].Err != nil {
+				continue
+			}
+			item := results[index].Item
+
+			mu.Lock()
+			if failed && mode == types.BatchModeAtomic {
+				mu.Unlock()
+				return
+			}
+			payment, roundUp, err := s.payBatchItem(item)
+			if err != nil {
+				failed = true
+			}
+			roundUps[index] = roundUp
+			mu.Unlock()
+
+			results[index].Payment = payment
+			results[index].Err = err
+		}
+	})
+
+	if mode == types.BatchModeBestEffort || !failed {
+		return results, nil
+	}
+
+	err := s.rollbackBatch(results, roundUps)
+	if err != nil {
+		return results, err
+	}
+	return results, ErrBatchFailed
+}
+
+// payBatchItem pays the item and returns the spare change swept into a
+// round-up pocket by the payment
+func (s *Service) payBatchItem(item types.BatchItem) (*types.Payment, batchRoundUp, error) {
+	pocket, _ := s.roundUp(item.AccountID, item.Amount)
+	pocketed := types.Money(0)
+	if pocket != nil {
+		pocketed = pocket.Balance
+	}
+
+	payment, err := s.Pay(item.AccountID, item.Amount, item.Category)
+	if err != nil || pocket == nil {
+		return payment, batchRoundUp{}, err
+	}
+	return payment, batchRoundUp{pocket: pocket, amount: pocket.Balance - pocketed}, nil
+}
+
+// rollbackBatch rejects paid items in reverse order and moves their round-ups
+// back from the pockets
+func (s *Service) rollbackBatch(results []BatchResult, roundUps []batchRoundUp) error {
+	for i := len(results) - 1; i >= 0; i-- {
+		if results[i].Payment == nil {
+			continue
+		}
+		err := s.Reject(results[i].Payment.ID)
+		if err != nil {
+			return err
+		}
+		if roundUp := roundUps[i]; roundUp.amount > 0 {
+			account, err := s.FindAccountByID(roundUp.pocket.AccountID)
+			if err != nil {
+				return err
+			}
+			roundUp.pocket.Balance -= roundUp.amount
+			account.Pocketed -= roundUp.amount
+		}
+		results[i].Err = ErrBatchFailed
+	}
+	return nil
+}
+
+// batchRoundUp spare change a batch payment moved into a pocket
+type batchRoundUp struct {
+	pocket *types.Pocket
+	amount types.Money
+}
+
+// runBatchGroups calls handle for every group from up to goroutines workers
+func runBatchGroups(groups [][]int, goroutines int, handle func(group []int)) {
+	wg := sync.WaitGroup{}
+	ch := make(chan []int)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range ch {
+				handle(group)
+			}
+		}()
+	}
+	for _, group := range groups {
+		ch <- group
+	}
+	close(ch)
+	wg.Wait()
+}
+
+// batchAccount balances of an account after the batch items validated so far
+type batchAccount struct {
+	balance   types.Money
+	available types.Money
+}
+
+// validateBatch sets Err of invalid items and reports whether all items are
+// valid. Valid items are simulated on balances, including fees, cashback and
+// round-ups, so that later items of the account see the effect of earlier
+// ones. Validation only reads the service state, so groups are checked in
+// parallel.
+func (s *Service) validateBatch(results []BatchResult, groups [][]int, goroutines int) bool {
+	s.ExpireHolds()
+	runBatchGroups(groups, goroutines, func(group []int) {
+		accounts := map[int64]*batchAccount{}
+		pending := []types.BatchItem{}
+		for _, index := range group {
+			item, err := s.validateBatchItem(results[index].Item, accounts, pending)
+			if err != nil {
+				results[index].Err = err
+				continue
+			}
+			pending = append(pending, item)
+		}
+	})
+
+	for _, result := range results {
+		if result.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Service) validateBatchItem(item types.BatchItem, accounts map[int64]*batchAccount, pending []types.BatchItem) (types.BatchItem, error) {
+	if item.Amount <= 0 {
+		return item, ErrAmountmustBePositive
+	}
+
+	account, err := s.FindAccountByID(item.AccountID)
+	if err != nil {
+		return item, err
+	}
+
+	err = checkAccountActive(account)
+	if err != nil {
+		return item, err
+	}
+
+	item.Category, err = s.ResolveCategory(string(item.Category))
+	if err != nil {
+		return item, err
+	}
+
+	err = s.checkSpendingLimits(account, item.Amount, item.Category, pending)
+	if err != nil {
+		return item, err
+	}
+
+	err = s.checkTierTurnover(account, s.pendingSpent(pending, account.ID, "")+item.Amount)
+	if err != nil {
+		return item, err
+	}
+
+	fee, err := s.fee(account, item.Amount, item.Category)
+	if err != nil {
+		return item, err
+	}
+	if fee > 0 {
+		revenue, err := s.revenueAccount()
+		if err != nil {
+			return item, err
+		}
+		if revenue.Currency != account.Currency {
+			return item, ErrCurrencyMismatch
+		}
+	}
+
+	state, ok := accounts[account.ID]
+	if !ok {
+		state = &batchAccount{balance: account.Balance, available: account.Available()}
+		accounts[account.ID] = state
+	}
+
+	total, err := money.Add(item.Amount, fee)
+	if err != nil {
+		return item, err
+	}
+	if total > state.available {
+		return item, ErrNotEnoughBalance
+	}
+
+	cashback, _, err := s.loyaltyAccrual(account, item.Amount, item.Category, state.balance-total)
+	if err != nil {
+		return item, err
+	}
+
+	state.balance += cashback - total
+	state.available += cashback - total
+	_, spare := s.roundUp(account.ID, item.Amount)
+	if spare > 0 && state.available >= spare {
+		state.available -= spare
+	}
+	return item, nil
+}
@@ -0,0 +1,218 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func (s *testService) addBatchAccounts(count int) ([]*types.Account, error) {
+	accounts := []*types.Account{}
+	for i := 0; i < count; i++ {
+		account, err := s.RegisterAccount(types.Phone(fmt.Sprintf("99200000%04d", i+10)))
+		if err != nil {
+			return nil, err
+		}
+		err = s.Deposit(account.ID, 1_000_00)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+func Test_PayBatch_success(t *testing.T) {
+	s := newTestService()
+	accounts, err := s.addBatchAccounts(4)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	items := []types.BatchItem{}
+	for _, account := range accounts {
+		for i := 0; i < 10; i++ {
+			items = append(items, types.BatchItem{AccountID: account.ID, Amount: 100_00, Category: "salary"})
+		}
+	}
+
+	results, err := s.PayBatch(items, types.BatchModeAtomic, 3)
+	if err != nil {
+		t.Errorf("PayBatch(): error = %v", err)
+		return
+	}
+
+	for i, result := range results {
+		if result.Index != i || result.Err != nil || result.Payment == nil || result.Payment.AccountID != items[i].AccountID {
+			t.Errorf("PayBatch(): wrong result = %v", result)
+			return
+		}
+	}
+	for _, account := range accounts {
+		if account.Balance != 0 {
+			t.Errorf("PayBatch(): wrong balance = %v", account)
+			return
+		}
+	}
+}
+
+func Test_PayBatch_bestEffort(t *testing.T) {
+	s := newTestService()
+	accounts, err := s.addBatchAccounts(2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	items := []types.BatchItem{
+		{AccountID: accounts[0].ID, Amount: 600_00, Category: "salary"},
+		{AccountID: accounts[0].ID, Amount: 600_00, Category: "salary"},
+		{AccountID: accounts[1].ID, Amount: 600_00, Category: "salary"},
+		{AccountID: 404, Amount: 600_00, Category: "salary"},
+	}
+	results, err := s.PayBatch(items, types.BatchModeBestEffort, 2)
+	if err != nil {
+		t.Errorf("PayBatch(): error = %v", err)
+		return
+	}
+
+	if results[0].Err != nil || results[1].Err != ErrNotEnoughBalance || results[2].Err != nil || results[3].Err != ErrAccountNotFound {
+		t.Errorf("PayBatch(): wrong results = %v", results)
+		return
+	}
+	if accounts[0].Balance != 400_00 || accounts[1].Balance != 400_00 {
+		t.Errorf("PayBatch(): wrong balances = %v, %v", accounts[0], accounts[1])
+		return
+	}
+}
+
+func Test_PayBatch_atomic(t *testing.T) {
+	s := newTestService()
+	accounts, err := s.addBatchAccounts(2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	items := []types.BatchItem{
+		{AccountID: accounts[0].ID, Amount: 600_00, Category: "salary"},
+		{AccountID: accounts[0].ID, Amount: 600_00, Category: "salary"},
+		{AccountID: accounts[1].ID, Amount: 100_00, Category: "salary"},
+	}
+	results, err := s.PayBatch(items, types.BatchModeAtomic, 2)
+	if err != ErrBatchFailed || results[1].Err != ErrNotEnoughBalance || results[0].Payment != nil {
+		t.Errorf("PayBatch(): must fail validation, results = %v, error = %v", results, err)
+		return
+	}
+
+	err = s.SetSpendingLimits(accounts[1].ID, types.SpendingLimits{PerTransaction: 50_00})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	items[1].Amount = 100_00
+	results, err = s.PayBatch(items, types.BatchModeAtomic, 2)
+	if err != ErrBatchFailed {
+		t.Errorf("PayBatch(): must return ErrBatchFailed, returned = %v", err)
+		return
+	}
+
+	if results[0].Err != nil || results[0].Payment != nil || !errors.Is(results[2].Err, ErrLimitExceeded) {
+		t.Errorf("PayBatch(): wrong results = %v", results)
+		return
+	}
+	for _, account := range accounts {
+		if account.Balance != 1_000_00 {
+			t.Errorf("PayBatch(): batch not validated before paying = %v", account)
+			return
+		}
+	}
+	if len(s.payments) != 0 {
+		t.Errorf("PayBatch(): payments created = %v", s.payments)
+		return
+	}
+}
+
+func Test_PayBatch_limits(t *testing.T) {
+	s := newTestService()
+	accounts, err := s.addBatchAccounts(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetSpendingLimits(accounts[0].ID, types.SpendingLimits{Daily: 150_00})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	items := []types.BatchItem{
+		{AccountID: accounts[0].ID, Amount: 100_00, Category: "salary"},
+		{AccountID: accounts[0].ID, Amount: 100_00, Category: "salary"},
+	}
+	results, err := s.PayBatch(items, types.BatchModeAtomic, 2)
+	if err != ErrBatchFailed || results[0].Err != nil || !errors.Is(results[1].Err, ErrLimitExceeded) {
+		t.Errorf("PayBatch(): must fail on daily limit, results = %v, error = %v", results, err)
+		return
+	}
+	if accounts[0].Balance != 1_000_00 || len(s.payments) != 0 {
+		t.Errorf("PayBatch(): paid before validation, account = %v", accounts[0])
+		return
+	}
+}
+
+func Test_rollbackBatch_roundUp(t *testing.T) {
+	s := newTestService()
+	accounts, err := s.addBatchAccounts(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	account := accounts[0]
+	pocket, err := s.CreatePocket(account.ID, "spare change", 0, time.Time{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetRoundUp(pocket.ID, 1_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	items := []types.BatchItem{
+		{AccountID: account.ID, Amount: 10_25, Category: "food"},
+		{AccountID: account.ID, Amount: 20_50, Category: "food"},
+	}
+	results := make([]BatchResult, len(items))
+	roundUps := make([]batchRoundUp, len(items))
+	for i, item := range items {
+		payment, roundUp, err := s.payBatchItem(item)
+		if err != nil {
+			t.Errorf("payBatchItem(): error = %v", err)
+			return
+		}
+		results[i] = BatchResult{Index: i, Item: item, Payment: payment}
+		roundUps[i] = roundUp
+	}
+	if pocket.Balance != 1_25 || roundUps[0].amount != 75 {
+		t.Errorf("payBatchItem(): wrong round-ups = %v, pocket = %v", roundUps, pocket)
+		return
+	}
+
+	err = s.rollbackBatch(results, roundUps)
+	if err != nil {
+		t.Errorf("rollbackBatch(): error = %v", err)
+		return
+	}
+	if account.Balance != 1_000_00 || account.Pocketed != 0 || pocket.Balance != 0 || results[0].Err != ErrBatchFailed {
+		t.Errorf("rollbackBatch(): round-ups not reversed, account = %v, pocket = %v", account, pocket)
+		return
+	}
+}
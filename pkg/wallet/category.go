@@ -0,0 +1,310 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+var ErrUnknownCategory = errors.New("unknown payment category")
+var ErrInvalidCategory = errors.New("invalid payment category")
+
+// DefaultCategories registry with common categories, install it with
+// SetCategoryRegistry
+var DefaultCategories = []types.CategoryInfo{
+	{ID: "transport", Names: map[string]string{"en": "Transport", "ru": "Транспорт", "tj": "Нақлиёт"}},
+	{ID: "auto", Parent: "transport", MCC: []string{"5533", "5541", "7538"}, Aliases: []string{"car", "cars"}, Names: map[string]string{"en": "Cars", "ru": "Автомобили", "tj": "Мошинҳо"}},
+	{ID: "taxi", Parent: "transport", MCC: []string{"4121"}, Names: map[string]string{"en": "Taxi", "ru": "Такси", "tj": "Таксӣ"}},
+	{ID: "communication", Names: map[string]string{"en": "Communication", "ru": "Связь", "tj": "Алоқа"}},
+	{ID: "mobile", Parent: "communication", MCC: []string{"4814"}, Aliases: []string{"phone"}, Names: map[string]string{"en": "Mobile", "ru": "Мобильная связь", "tj": "Алоқаи мобилӣ"}},
+	{ID: "internet", Parent: "communication", MCC: []string{"4816"}, Names: map[string]string{"en": "Internet", "ru": "Интернет", "tj": "Интернет"}},
+	{ID: "utility", MCC: []string{"4900"}, Aliases: []string{"utilities"}, Names: map[string]string{"en": "Utilities", "ru": "Коммунальные услуги", "tj": "Хизматрасониҳои коммуналӣ"}},
+	{ID: "health", Names: map[string]string{"en": "Health", "ru": "Здоровье", "tj": "Саломатӣ"}},
+	{ID: "pharmacy", Parent: "health", MCC: []string{"5912"}, Aliases: []string{"pharmacies", "drugstore"}, Names: map[string]string{"en": "Pharmacy", "ru": "Аптеки", "tj": "Дорухонаҳо"}},
+	{ID: "food", MCC: []string{"5411", "5812", "5814"}, Aliases: []string{"grocery", "restaurant"}, Names: map[string]string{"en": "Food", "ru": "Еда", "tj": "Хӯрок"}},
+	{ID: "shop", MCC: []string{"5311", "5399"}, Aliases: []string{"shopping"}, Names: map[string]string{"en": "Shopping", "ru": "Покупки", "tj": "Харид"}},
+	{ID: "salary", Names: map[string]string{"en": "Salary", "ru": "Зарплата", "tj": "Музди меҳнат"}},
+}
+
+// SetCategoryRegistry replaces the category registry. IDs are canonical
+// lowercase names, aliases and MCC must be unique and parents registered.
+// While the registry is empty any category is accepted as is, otherwise
+// payments resolve aliases and fail for unknown categories. Categories of
+// fee and cashback rules, spending limits and merchants are resolved again,
+// the registry is rejected with ErrUnknownCategory if one of them is unknown.
+func (s *Service) SetCategoryRegistry(categories []types.CategoryInfo) error {
+	names := map[string]bool{}
+	mcc := map[string]bool{}
+	for _, category := range categories {
+		id := string(category.ID)
+		if id == "" || id != normalizeCategory(id) || names[id] {
+			return ErrInvalidCategory
+		}
+		names[id] = true
+
+		for _, code := range category.MCC {
+			if !validMCC(code) || mcc[code] {
+				return ErrInvalidCategory
+			}
+			mcc[code] = true
+		}
+	}
+	for _, category := range categories {
+		for _, alias := range category.Aliases {
+			alias = normalizeCategory(alias)
+			if alias == "" || names[alias] {
+				return ErrInvalidCategory
+			}
+			names[alias] = true
+		}
+	}
+
+	registry := make([]types.CategoryInfo, len(categories))
+	copy(registry, categories)
+	for _, category := range registry {
+		// every ancestor must be registered and the chain must not loop
+		parent := category.Parent
+		for depth := 0; parent != ""; depth++ {
+			info := findCategory(registry, parent)
+			if info == nil || depth == len(registry) {
+				return ErrInvalidCategory
+			}
+			parent = info.Parent
+		}
+	}
+
+	return s.installRegistry(registry)
+}
+
+// installRegistry installs registry and resolves categories of stored fee
+// and cashback rules, spending limits and merchants with it. When any of
+// them is unknown to the registry nothing is changed.
+func (s *Service) installRegistry(registry []types.CategoryInfo) error {
+	resolve := func(category types.PaymentCategory) (types.PaymentCategory, error) {
+		if category == "" {
+			return "", nil
+		}
+		return resolveCategory(registry, string(category))
+	}
+
+	feeRules := append([]types.FeeRule{}, s.feeRules...)
+	for i := range feeRules {
+		category, err := resolve(feeRules[i].Category)
+		if err != nil {
+			return err
+		}
+		feeRules[i].Category = category
+	}
+
+	cashbackRules := append([]types.CashbackRule{}, s.cashbackRules...)
+	for i := range cashbackRules {
+		category, err := resolve(cashbackRules[i].Category)
+		if err != nil {
+			return err
+		}
+		cashbackRules[i].Category = category
+	}
+
+	limits := map[int64]types.SpendingLimits{}
+	for accountID, limit := range s.limits {
+		categories := map[types.PaymentCategory]types.Money{}
+		for key, amount := range limit.Categories {
+			category, err := resolve(key)
+			if err != nil {
+				return err
+			}
+			// keys merged into one category keep the strictest limit
+			if current, ok := categories[category]; !ok || amount < current {
+				categories[category] = amount
+			}
+		}
+		limit.Categories = categories
+		limits[accountID] = limit
+	}
+
+	merchants := make([]types.PaymentCategory, len(s.merchants))
+	for i, merchant := range s.merchants {
+		category, err := resolve(merchant.Category)
+		if err != nil {
+			return err
+		}
+		merchants[i] = category
+	}
+
+	s.categories = registry
+	s.feeRules = feeRules
+	s.cashbackRules = cashbackRules
+	if s.limits != nil {
+		s.limits = limits
+	}
+	for i, merchant := range s.merchants {
+		merchant.Category = merchants[i]
+	}
+	return nil
+}
+
+// ResolveCategory returns canonical ID of category given by ID or alias in
+// any case
+func (s *Service) ResolveCategory(name string) (types.PaymentCategory, error) {
+	return resolveCategory(s.categories, name)
+}
+
+func resolveCategory(registry []types.CategoryInfo, name string) (types.PaymentCategory, error) {
+	if len(registry) == 0 {
+		return types.PaymentCategory(name), nil
+	}
+
+	name = normalizeCategory(name)
+	for _, category := range registry {
+		if string(category.ID) == name {
+			return category.ID, nil
+		}
+		for _, alias := range category.Aliases {
+			if normalizeCategory(alias) == name {
+				return category.ID, nil
+			}
+		}
+	}
+	return "", ErrUnknownCategory
+}
+
+// NormalizeCategories rewrites categories of stored payments, favorites and
+// standing orders, for example imported from old dumps, to canonical IDs and
+// returns the number of changed records. Unknown categories are kept.
+func (s *Service) NormalizeCategories() int {
+	count := 0
+	normalize := func(category *types.PaymentCategory) {
+		id, err := s.ResolveCategory(string(*category))
+		if err == nil && id != *category {
+			*category = id
+			count++
+		}
+	}
+
+	for _, payment := range s.payments {
+		normalize(&payment.Category)
+	}
+	for _, favorite := range s.favorites {
+		normalize(&favorite.Category)
+	}
+	for _, order := range s.orders {
+		if order.Category != "" {
+			normalize(&order.Category)
+		}
+	}
+	return count
+}
+
+// CategoryByMCC returns category of merchant category code
+func (s *Service) CategoryByMCC(mcc string) (types.PaymentCategory, error) {
+	for _, category := range s.categories {
+		for _, code := range category.MCC {
+			if code == mcc {
+				return category.ID, nil
+			}
+		}
+	}
+	return "", ErrUnknownCategory
+}
+
+// Category returns registry entry of category given by ID or alias
+func (s *Service) Category(name string) (types.CategoryInfo, error) {
+	id, err := s.ResolveCategory(name)
+	if err != nil {
+		return types.CategoryInfo{}, err
+	}
+
+	category := findCategory(s.categories, id)
+	if category == nil {
+		return types.CategoryInfo{}, ErrUnknownCategory
+	}
+	return *category, nil
+}
+
+// Subcategories returns direct children of category
+func (s *Service) Subcategories(id types.PaymentCategory) []types.CategoryInfo {
+	children := []types.CategoryInfo{}
+	for _, category := range s.categories {
+		if category.Parent == id {
+			children = append(children, category)
+		}
+	}
+	return children
+}
+
+// InCategory reports whether category is ancestor or the category itself
+func (s *Service) InCategory(category types.PaymentCategory, ancestor types.PaymentCategory) bool {
+	return s.categoryDepth(category, ancestor) >= 0
+}
+
+// categoryDepth returns how many levels ancestor is above category, 0 for the
+// category itself and -1 when it is not an ancestor
+func (s *Service) categoryDepth(category types.PaymentCategory, ancestor types.PaymentCategory) int {
+	for depth := 0; category != "" && depth <= len(s.categories); depth++ {
+		if category == ancestor {
+			return depth
+		}
+		info := findCategory(s.categories, category)
+		if info == nil {
+			return -1
+		}
+		category = info.Parent
+	}
+	return -1
+}
+
+// categoryPath returns category followed by its ancestors
+func (s *Service) categoryPath(category types.PaymentCategory) []types.PaymentCategory {
+	path := []types.PaymentCategory{}
+	for depth := 0; category != "" && depth <= len(s.categories); depth++ {
+		path = append(path, category)
+		info := findCategory(s.categories, category)
+		if info == nil {
+			break
+		}
+		category = info.Parent
+	}
+	return path
+}
+
+// CategoryName returns display name of category in language, falling back to
+// English and then to the ID
+func (s *Service) CategoryName(id types.PaymentCategory, language string) string {
+	category := findCategory(s.categories, id)
+	if category == nil {
+		return string(id)
+	}
+	if name, ok := category.Names[language]; ok {
+		return name
+	}
+	if name, ok := category.Names["en"]; ok {
+		return name
+	}
+	return string(id)
+}
+
+func findCategory(categories []types.CategoryInfo, id types.PaymentCategory) *types.CategoryInfo {
+	for i := range categories {
+		if categories[i].ID == id {
+			return &categories[i]
+		}
+	}
+	return nil
+}
+
+func normalizeCategory(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func validMCC(code string) bool {
+	if len(code) != 4 {
+		return false
+	}
+	for _, char := range code {
+		if char < '0' || char > '9' {
+			return false
+		}
+	}
+	return true
+}
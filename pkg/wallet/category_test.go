@@ -0,0 +1,234 @@
+package wallet
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_SetCategoryRegistry_fail(t *testing.T) {
+	s := newTestService()
+
+	tests := [][]types.CategoryInfo{
+		{{ID: "Auto"}},
+		{{ID: "auto"}, {ID: "auto"}},
+		{{ID: "auto", Aliases: []string{"car"}}, {ID: "car"}},
+		{{ID: "auto", MCC: []string{"55"}}},
+		{{ID: "auto", MCC: []string{"5533"}}, {ID: "parts", MCC: []string{"5533"}}},
+		{{ID: "auto", Parent: "transport"}},
+		{{ID: "a", Parent: "b"}, {ID: "b", Parent: "a"}},
+	}
+	for _, categories := range tests {
+		err := s.SetCategoryRegistry(categories)
+		if err != ErrInvalidCategory {
+			t.Errorf("SetCategoryRegistry(): must return ErrInvalidCategory for %v, returned = %v", categories, err)
+			return
+		}
+	}
+}
+
+func Test_Pay_category(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	legacy, err := s.Pay(account.ID, 10_00, "car")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	err = s.SetCategoryRegistry(DefaultCategories)
+	if err != nil {
+		t.Errorf("SetCategoryRegistry(): error = %v", err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 10_00, " Car ")
+	if err != nil || payment.Category != "auto" {
+		t.Errorf("Pay(): payment = %v, error = %v", payment, err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 10_00, "cars and bikes")
+	if err != ErrUnknownCategory {
+		t.Errorf("Pay(): must return ErrUnknownCategory, returned = %v", err)
+		return
+	}
+
+	count := s.NormalizeCategories()
+	if count != 1 || legacy.Category != "auto" {
+		t.Errorf("NormalizeCategories(): count = %v, payment = %v", count, legacy)
+		return
+	}
+}
+
+func Test_Pay_parentCategoryRules(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.addRevenueAccount()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetCategoryRegistry(DefaultCategories)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetSpendingLimits(account.ID, types.SpendingLimits{Categories: map[types.PaymentCategory]types.Money{"bikes": 1_00}})
+	if err != ErrUnknownCategory {
+		t.Errorf("SetSpendingLimits(): must return ErrUnknownCategory, returned = %v", err)
+		return
+	}
+	err = s.SetSpendingLimits(account.ID, types.SpendingLimits{Categories: map[types.PaymentCategory]types.Money{"transport": 1_150_00}})
+	if err != nil {
+		t.Errorf("SetSpendingLimits(): error = %v", err)
+		return
+	}
+	err = s.SetFeeSchedule([]types.FeeRule{
+		{Category: "transport", Kind: types.FeeFlat, Flat: 1_00},
+		{Category: "car", Kind: types.FeeFlat, Flat: 2_00},
+	})
+	if err != nil {
+		t.Errorf("SetFeeSchedule(): error = %v", err)
+		return
+	}
+	err = s.SetCashbackRules([]types.CashbackRule{{Category: "transport", Percent: 100}})
+	if err != nil {
+		t.Errorf("SetCashbackRules(): error = %v", err)
+		return
+	}
+
+	taxi, err := s.Pay(account.ID, 100_00, "taxi")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	history, err := s.LoyaltyHistory(account.ID)
+	if err != nil || taxi.Fee != 1_00 || len(history) != 1 || history[0].Cashback != 1_00 {
+		t.Errorf("Pay(): parent rules not applied, payment = %v, history = %v", taxi, history)
+		return
+	}
+
+	fee, err := s.CalculateFee(account.ID, 10_00, "auto")
+	if err != nil || fee != 2_00 {
+		t.Errorf("CalculateFee(): fee = %v, error = %v", fee, err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 60_00, "auto")
+	limitErr, ok := err.(*LimitError)
+	if !ok || limitErr.Kind != LimitCategory || limitErr.Category != "transport" {
+		t.Errorf("Pay(): must exceed transport limit, returned = %v", err)
+		return
+	}
+}
+
+func Test_SetCategoryRegistry_storedCategories(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.addRevenueAccount()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetFeeSchedule([]types.FeeRule{{Category: "car", Kind: types.FeeFlat, Flat: 10}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetSpendingLimits(account.ID, types.SpendingLimits{Categories: map[types.PaymentCategory]types.Money{"cars": 1}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	merchant, err := s.RegisterMerchant("Car Shop", "Car", "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetCategoryRegistry(DefaultCategories)
+	if err != nil {
+		t.Errorf("SetCategoryRegistry(): error = %v", err)
+		return
+	}
+
+	fee, err := s.CalculateFee(account.ID, 100, "auto")
+	if err != nil || fee != 10 || merchant.Category != "auto" {
+		t.Errorf("SetCategoryRegistry(): fee = %v, merchant = %v, error = %v", fee, merchant, err)
+		return
+	}
+	_, err = s.Pay(account.ID, 100, "auto")
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Pay(): must return ErrLimitExceeded, returned = %v", err)
+		return
+	}
+
+	err = s.SetCashbackRules([]types.CashbackRule{{Category: "car", Percent: 100}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetCategoryRegistry([]types.CategoryInfo{{ID: "food"}})
+	if err != ErrUnknownCategory {
+		t.Errorf("SetCategoryRegistry(): must return ErrUnknownCategory, returned = %v", err)
+		return
+	}
+	if _, err = s.ResolveCategory("car"); err != nil {
+		t.Errorf("SetCategoryRegistry(): rejected registry installed, error = %v", err)
+		return
+	}
+}
+
+func Test_CategoryRegistry(t *testing.T) {
+	s := newTestService()
+	err := s.SetCategoryRegistry(DefaultCategories)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	category, err := s.CategoryByMCC("5912")
+	if err != nil || category != "pharmacy" {
+		t.Errorf("CategoryByMCC(): category = %v, error = %v", category, err)
+		return
+	}
+
+	info, err := s.Category("CAR")
+	if err != nil || info.ID != "auto" || info.Parent != "transport" {
+		t.Errorf("Category(): info = %v, error = %v", info, err)
+		return
+	}
+
+	if !s.InCategory("auto", "transport") || s.InCategory("auto", "health") {
+		t.Errorf("InCategory(): wrong hierarchy")
+		return
+	}
+
+	children := s.Subcategories("communication")
+	if len(children) != 2 || children[0].ID != "mobile" || children[1].ID != "internet" {
+		t.Errorf("Subcategories(): children = %v", children)
+		return
+	}
+
+	if s.CategoryName("pharmacy", "ru") != "Аптеки" || s.CategoryName("pharmacy", "de") != "Pharmacy" || s.CategoryName("other", "en") != "other" {
+		t.Errorf("CategoryName(): wrong names")
+		return
+	}
+}
@@ -0,0 +1,26 @@
+package wallet
+
+import "time"
+
+// Clock source of the current time used by the service
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SetClock replaces the clock used by the service, nil restores the system clock
+func (s *Service) SetClock(clock Clock) {
+	s.clock = clock
+}
+
+func (s *Service) now() time.Time {
+	if s.clock == nil {
+		return systemClock{}.Now()
+	}
+	return s.clock.Now()
+}
@@ -0,0 +1,105 @@
+package wallet
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+// escapeField escapes free-form text so it can be stored in a dump column
+func escapeField(str string) string {
+	return url.QueryEscape(str)
+}
+
+func unescapeField(str string) (string, error) {
+	return url.QueryUnescape(str)
+}
+
+// encodeMetadata encodes map as escaped key=value pairs joined by &
+func encodeMetadata(metadata map[string]string) string {
+	values := url.Values{}
+	for key, value := range metadata {
+		values.Set(key, value)
+	}
+	return values.Encode()
+}
+
+func decodeMetadata(str string) (map[string]string, error) {
+	if str == "" {
+		return nil, nil
+	}
+
+	values, err := url.ParseQuery(str)
+	if err != nil {
+		return nil, err
+	}
+	metadata := map[string]string{}
+	for key := range values {
+		metadata[key] = values.Get(key)
+	}
+	return metadata, nil
+}
+
+// encodeTags escapes tags and joins them by commas
+func encodeTags(tags []string) string {
+	encoded := []string{}
+	for _, tag := range tags {
+		encoded = append(encoded, escapeField(tag))
+	}
+	return strings.Join(encoded, ",")
+}
+
+func decodeTags(str string) ([]string, error) {
+	if str == "" {
+		return nil, nil
+	}
+
+	tags := []string{}
+	for _, encoded := range strings.Split(str, ",") {
+		tag, err := unescapeField(encoded)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// encodeTemplateFields encodes fields as name:required:pattern joined by commas
+func encodeTemplateFields(fields []types.TemplateField) string {
+	encoded := []string{}
+	for _, field := range fields {
+		encoded = append(encoded, escapeField(field.Name)+":"+strconv.FormatBool(field.Required)+":"+escapeField(field.Pattern))
+	}
+	return strings.Join(encoded, ",")
+}
+
+func decodeTemplateFields(str string) ([]types.TemplateField, error) {
+	if str == "" {
+		return nil, nil
+	}
+
+	fields := []types.TemplateField{}
+	for _, encoded := range strings.Split(str, ",") {
+		parts := strings.Split(encoded, ":")
+		if len(parts) != 3 {
+			return nil, ErrInvalidTemplate
+		}
+		name, err := unescapeField(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		required, err := strconv.ParseBool(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		pattern, err := unescapeField(parts[2])
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, types.TemplateField{Name: name, Required: required, Pattern: pattern})
+	}
+	return fields, nil
+}
@@ -0,0 +1,70 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/fm2901/wallet/pkg/exchange"
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+var ErrNoRateProvider = errors.New("exchange rate provider is not set")
+
+// SetRateProvider sets the source of exchange rates used by PayConverted
+func (s *Service) SetRateProvider(provider exchange.Provider) {
+	s.rateProvider = provider
+}
+
+// SetExchangeSpread sets markup in basis points added to every exchange rate
+func (s *Service) SetExchangeSpread(spread int64) {
+	s.exchangeSpread = spread
+}
+
+// SetExchangeRounding sets rounding of converted amounts
+func (s *Service) SetExchangeRounding(rounding exchange.Rounding) {
+	s.exchangeRounding = rounding
+}
+
+// PayConverted pays amount in any currency from the account, converting it to
+// the account currency. The applied rate is recorded on the payment.
+func (s *Service) PayConverted(accountID int64, amount types.Amount, category types.PaymentCategory) (*types.Payment, error) {
+	if amount.Value <= 0 {
+		return nil, ErrAmountmustBePositive
+	}
+
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount.Currency == account.Currency {
+		return s.Pay(accountID, amount.Value, category)
+	}
+
+	if !amount.Currency.Valid() {
+		return nil, ErrUnknownCurrency
+	}
+	if s.rateProvider == nil {
+		return nil, ErrNoRateProvider
+	}
+
+	rate, err := s.rateProvider.Rate(amount.Currency, account.Currency)
+	if err != nil {
+		return nil, err
+	}
+	rate = exchange.ApplySpread(rate, s.exchangeSpread)
+
+	converted, err := exchange.Convert(amount, account.Currency, rate, s.exchangeRounding)
+	if err != nil {
+		return nil, err
+	}
+
+	payment, err := s.Pay(accountID, converted.Value, category)
+	if err != nil {
+		return nil, err
+	}
+
+	payment.OriginalAmount = amount.Value
+	payment.OriginalCurrency = amount.Currency
+	payment.ExchangeRate = exchange.FormatRate(rate)
+	return payment, nil
+}
@@ -0,0 +1,76 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fm2901/wallet/pkg/exchange"
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_PayConverted_success(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	provider, err := exchange.ReadCSV(strings.NewReader("USD,TJS,11.30"))
+	if err != nil {
+		t.Errorf("ReadCSV(): error = %v", err)
+		return
+	}
+	s.SetRateProvider(provider)
+	s.SetExchangeSpread(100)
+
+	balance := account.Balance
+	payment, err := s.PayConverted(account.ID, types.Amount{Value: 10_00, Currency: types.CurrencyUSD}, "auto")
+	if err != nil {
+		t.Errorf("PayConverted(): error = %v", err)
+		return
+	}
+
+	if payment.Amount != 114_13 || payment.Currency != types.CurrencyTJS {
+		t.Errorf("PayConverted(): wrong amount, payment = %v", payment)
+		return
+	}
+
+	if payment.OriginalAmount != 10_00 || payment.OriginalCurrency != types.CurrencyUSD || payment.ExchangeRate != "11.413" {
+		t.Errorf("PayConverted(): rate not recorded, payment = %v", payment)
+		return
+	}
+
+	if account.Balance != balance-payment.Amount {
+		t.Errorf("PayConverted(): wrong balance, account = %v", account)
+		return
+	}
+}
+
+func Test_PayConverted_fail(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.PayConverted(account.ID, types.Amount{Value: 10_00, Currency: types.CurrencyUSD}, "auto")
+	if err != ErrNoRateProvider {
+		t.Errorf("PayConverted(): must return ErrNoRateProvider, returned = %v", err)
+		return
+	}
+
+	provider, err := exchange.ReadCSV(strings.NewReader("USD,TJS,11.30"))
+	if err != nil {
+		t.Errorf("ReadCSV(): error = %v", err)
+		return
+	}
+	s.SetRateProvider(provider)
+
+	_, err = s.PayConverted(account.ID, types.Amount{Value: 10_00, Currency: types.CurrencyEUR}, "auto")
+	if err != exchange.ErrRateNotFound {
+		t.Errorf("PayConverted(): must return ErrRateNotFound, returned = %v", err)
+		return
+	}
+}
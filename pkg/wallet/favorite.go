@@ -0,0 +1,141 @@
+package wallet
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+var ErrInvalidFavoriteName = errors.New("favorite name is empty")
+var ErrFavoriteNameTaken = errors.New("favorite name already used")
+var ErrInvalidPosition = errors.New("invalid favorite position")
+
+// Favorites returns favorites of the account in user defined order
+func (s *Service) Favorites(accountID int64) ([]types.Favorite, error) {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	favorites := []types.Favorite{}
+	for _, favorite := range s.accountFavorites(account.ID) {
+		favorites = append(favorites, *favorite)
+	}
+	return favorites, nil
+}
+
+// RenameFavorite changes favorite name, names are unique per account
+func (s *Service) RenameFavorite(favoriteID string, name string) error {
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return err
+	}
+
+	name, err = s.checkFavoriteName(favorite.AccountID, favorite.ID, name)
+	if err != nil {
+		return err
+	}
+	favorite.Name = name
+	return nil
+}
+
+// SetFavoriteAmount changes amount paid by the favorite, for a template it
+// must be within the template range
+func (s *Service) SetFavoriteAmount(favoriteID string, amount types.Money) error {
+	if amount <= 0 {
+		return ErrAmountmustBePositive
+	}
+
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return err
+	}
+	if favorite.MaxAmount > 0 && (amount < favorite.MinAmount || amount > favorite.MaxAmount) {
+		return ErrAmountOutOfRange
+	}
+	favorite.Amount = amount
+	return nil
+}
+
+// DeleteFavorite removes favorite and cancels its standing orders
+func (s *Service) DeleteFavorite(favoriteID string) error {
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return err
+	}
+
+	for i, v := range s.favorites {
+		if v.ID == favorite.ID {
+			s.favorites = append(s.favorites[:i], s.favorites[i+1:]...)
+			break
+		}
+	}
+	for i, v := range s.accountFavorites(favorite.AccountID) {
+		v.Position = i
+	}
+
+	for _, order := range s.orders {
+		if order.FavoriteID == favorite.ID {
+			order.Active = false
+		}
+	}
+	return nil
+}
+
+// MoveFavorite moves favorite to position in the account favorites list,
+// other favorites are shifted
+func (s *Service) MoveFavorite(favoriteID string, position int) error {
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return err
+	}
+
+	favorites := s.accountFavorites(favorite.AccountID)
+	if position < 0 || position >= len(favorites) {
+		return ErrInvalidPosition
+	}
+
+	ordered := []*types.Favorite{}
+	for _, v := range favorites {
+		if v.ID != favorite.ID {
+			ordered = append(ordered, v)
+		}
+	}
+	ordered = append(ordered[:position], append([]*types.Favorite{favorite}, ordered[position:]...)...)
+	for i, v := range ordered {
+		v.Position = i
+	}
+	return nil
+}
+
+// accountFavorites returns favorites of the account sorted by position
+func (s *Service) accountFavorites(accountID int64) []*types.Favorite {
+	favorites := []*types.Favorite{}
+	for _, favorite := range s.favorites {
+		if favorite.AccountID == accountID {
+			favorites = append(favorites, favorite)
+		}
+	}
+	sort.SliceStable(favorites, func(i, j int) bool {
+		return favorites[i].Position < favorites[j].Position
+	})
+	return favorites
+}
+
+// checkFavoriteName trims the name and checks that no other favorite of the
+// account has it
+func (s *Service) checkFavoriteName(accountID int64, favoriteID string, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", ErrInvalidFavoriteName
+	}
+
+	for _, favorite := range s.favorites {
+		if favorite.AccountID == accountID && favorite.ID != favoriteID && strings.EqualFold(favorite.Name, name) {
+			return "", ErrFavoriteNameTaken
+		}
+	}
+	return name, nil
+}
@@ -0,0 +1,159 @@
+package wallet
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func (s *testService) addFavorites(names ...string) (*types.Account, []*types.Favorite, error) {
+	account, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	favorites := []*types.Favorite{}
+	for _, name := range names {
+		favorite, err := s.FavoritePayment(payments[0].ID, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		favorites = append(favorites, favorite)
+	}
+	return account, favorites, nil
+}
+
+func Test_Favorites_order(t *testing.T) {
+	s := newTestService()
+	account, favorites, err := s.addFavorites("home", "work", "gym")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.MoveFavorite(favorites[2].ID, 0)
+	if err != nil {
+		t.Errorf("MoveFavorite(): error = %v", err)
+		return
+	}
+
+	err = s.DeleteFavorite(favorites[0].ID)
+	if err != nil {
+		t.Errorf("DeleteFavorite(): error = %v", err)
+		return
+	}
+
+	list, err := s.Favorites(account.ID)
+	if err != nil {
+		t.Errorf("Favorites(): error = %v", err)
+		return
+	}
+
+	if len(list) != 2 || list[0].Name != "gym" || list[1].Name != "work" || list[1].Position != 1 {
+		t.Errorf("Favorites(): wrong order = %v", list)
+		return
+	}
+
+	_, err = s.FindFavoriteByID(favorites[0].ID)
+	if err != ErrFavoriteNotFound {
+		t.Errorf("FindFavoriteByID(): must return ErrFavoriteNotFound, returned = %v", err)
+		return
+	}
+
+	err = s.MoveFavorite(favorites[1].ID, 2)
+	if err != ErrInvalidPosition {
+		t.Errorf("MoveFavorite(): must return ErrInvalidPosition, returned = %v", err)
+		return
+	}
+}
+
+func Test_RenameFavorite(t *testing.T) {
+	s := newTestService()
+	_, favorites, err := s.addFavorites("new", "work")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.RenameFavorite(favorites[0].ID, " Work ")
+	if err != ErrFavoriteNameTaken {
+		t.Errorf("RenameFavorite(): must return ErrFavoriteNameTaken, returned = %v", err)
+		return
+	}
+
+	err = s.RenameFavorite(favorites[0].ID, "  ")
+	if err != ErrInvalidFavoriteName {
+		t.Errorf("RenameFavorite(): must return ErrInvalidFavoriteName, returned = %v", err)
+		return
+	}
+
+	err = s.RenameFavorite(favorites[0].ID, "Mom's phone; +992")
+	if err != nil {
+		t.Errorf("RenameFavorite(): error = %v", err)
+		return
+	}
+
+	err = s.SetFavoriteAmount(favorites[0].ID, 25_00)
+	if err != nil {
+		t.Errorf("SetFavoriteAmount(): error = %v", err)
+		return
+	}
+
+	payment, err := s.PayFromFavorite(favorites[0].ID)
+	if err != nil || payment.Amount != 25_00 {
+		t.Errorf("PayFromFavorite(): payment = %v, error = %v", payment, err)
+		return
+	}
+
+	_, err = s.FavoritePayment(payment.ID, "work")
+	if err != ErrFavoriteNameTaken {
+		t.Errorf("FavoritePayment(): must return ErrFavoriteNameTaken, returned = %v", err)
+		return
+	}
+}
+
+func Test_Import_favorites(t *testing.T) {
+	s := newTestService()
+	account, favorites, err := s.addFavorites("home", "work")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.RenameFavorite(favorites[1].ID, "office; 2nd floor\n100%")
+	if err != nil {
+		t.Errorf("RenameFavorite(): error = %v", err)
+		return
+	}
+	err = s.MoveFavorite(favorites[1].ID, 0)
+	if err != nil {
+		t.Errorf("MoveFavorite(): error = %v", err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+
+	list, err := imported.Favorites(account.ID)
+	if err != nil {
+		t.Errorf("Favorites(): error = %v", err)
+		return
+	}
+
+	if !reflect.DeepEqual(list, []types.Favorite{*favorites[1], *favorites[0]}) {
+		t.Errorf("Import(): wrong favorites = %v", list)
+		return
+	}
+}
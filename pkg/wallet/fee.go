@@ -0,0 +1,147 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/fm2901/wallet/pkg/money"
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+var ErrInvalidFeeRule = errors.New("invalid fee rule")
+var ErrNoRevenueAccount = errors.New("revenue account is not set")
+
+// SetFeeSchedule replaces fee rules. For a payment the most specific rule is
+// used: category and tier, then category, then tier, then the default rule.
+// A category rule also covers subcategories unless they have their own rule.
+func (s *Service) SetFeeSchedule(rules []types.FeeRule) error {
+	rules = append([]types.FeeRule{}, rules...)
+	for i := range rules {
+		rule := &rules[i]
+		switch rule.Kind {
+		case types.FeeFlat, types.FeePercent:
+		case types.FeeTiered:
+			if len(rule.Tiers) == 0 {
+				return ErrInvalidFeeRule
+			}
+		default:
+			return ErrInvalidFeeRule
+		}
+		if rule.Flat < 0 || rule.Percent < 0 || rule.Min < 0 || rule.Max < 0 || (rule.Max > 0 && rule.Min > rule.Max) {
+			return ErrInvalidFeeRule
+		}
+		if rule.Category != "" {
+			category, err := s.ResolveCategory(string(rule.Category))
+			if err != nil {
+				return err
+			}
+			rule.Category = category
+		}
+	}
+
+	s.feeRules = rules
+	return nil
+}
+
+// SetRevenueAccount sets account which receives collected fees
+func (s *Service) SetRevenueAccount(accountID int64) error {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+	s.revenueAccountID = account.ID
+	return nil
+}
+
+// CalculateFee returns the fee the account would pay for the payment
+func (s *Service) CalculateFee(accountID int64, amount types.Money, category types.PaymentCategory) (types.Money, error) {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return 0, err
+	}
+	return s.fee(account, amount, category)
+}
+
+func (s *Service) fee(account *types.Account, amount types.Money, category types.PaymentCategory) (types.Money, error) {
+	rule := s.feeRule(account.Tier, category)
+	if rule == nil {
+		return 0, nil
+	}
+
+	flat := rule.Flat
+	percent := rule.Percent
+	if rule.Kind == types.FeeTiered {
+		flat, percent = 0, 0
+		for _, tier := range rule.Tiers {
+			if amount >= tier.From {
+				flat, percent = tier.Flat, tier.Percent
+			}
+		}
+	}
+	if rule.Kind == types.FeeFlat {
+		percent = 0
+	}
+	if rule.Kind == types.FeePercent {
+		flat = 0
+	}
+
+	fee, err := money.Percent(amount, percent)
+	if err != nil {
+		return 0, err
+	}
+	fee, err = money.Add(fee, flat)
+	if err != nil {
+		return 0, err
+	}
+
+	if fee < rule.Min {
+		fee = rule.Min
+	}
+	if rule.Max > 0 && fee > rule.Max {
+		fee = rule.Max
+	}
+	return fee, nil
+}
+
+func (s *Service) feeRule(tier types.Tier, category types.PaymentCategory) *types.FeeRule {
+	var best *types.FeeRule
+	bestScore := -1
+	for i := range s.feeRules {
+		rule := &s.feeRules[i]
+		depth := 0
+		if rule.Category != "" {
+			depth = s.categoryDepth(category, rule.Category)
+		}
+		if depth < 0 || (rule.Tier != "" && rule.Tier != tier) {
+			continue
+		}
+
+		// the closest category wins, tier breaks ties
+		score := 0
+		if rule.Category != "" {
+			score += 2 * (len(s.categories) + 1 - depth)
+		}
+		if rule.Tier != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = rule, score
+		}
+	}
+	return best
+}
+
+func (s *Service) revenueAccount() (*types.Account, error) {
+	if s.revenueAccountID == 0 {
+		return nil, ErrNoRevenueAccount
+	}
+	return s.FindAccountByID(s.revenueAccountID)
+}
+
+// feeAccount returns the account credited with the payment fee, payments
+// from dumps without it use the current revenue account
+func (s *Service) feeAccount(payment *types.Payment) (*types.Account, error) {
+	if payment.FeeAccountID == 0 {
+		return s.revenueAccount()
+	}
+	return s.FindAccountByID(payment.FeeAccountID)
+}
@@ -0,0 +1,231 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func (s *testService) addRevenueAccount() (*types.Account, error) {
+	revenue, err := s.RegisterAccount("992900000000")
+	if err != nil {
+		return nil, err
+	}
+	err = s.UpgradeTier(revenue.ID, types.TierFull, "revenue account")
+	if err != nil {
+		return nil, err
+	}
+	return revenue, s.SetRevenueAccount(revenue.ID)
+}
+
+func Test_CalculateFee(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.UpgradeTier(account.ID, types.TierFull, "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetFeeSchedule([]types.FeeRule{
+		{Kind: types.FeeFlat, Flat: 1_00},
+		{Category: "mobile", Kind: types.FeeFlat},
+		{Category: "auto", Kind: types.FeePercent, Percent: 100, Min: 5_00, Max: 50_00},
+		{Category: "auto", Tier: types.TierAnonymous, Kind: types.FeePercent, Percent: 200},
+		{Category: "pharmacy", Kind: types.FeeTiered, Tiers: []types.FeeTier{
+			{From: 0, Flat: 2_00},
+			{From: 1_000_00, Percent: 50},
+		}},
+	})
+	if err != nil {
+		t.Errorf("SetFeeSchedule(): error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		amount   types.Money
+		category types.PaymentCategory
+		want     types.Money
+	}{
+		{100_00, "food", 1_00},
+		{100_00, "mobile", 0},
+		{100_00, "auto", 5_00},
+		{1_000_00, "auto", 10_00},
+		{10_000_00, "auto", 50_00},
+		{500_00, "pharmacy", 2_00},
+		{2_000_00, "pharmacy", 10_00},
+	}
+
+	for _, test := range tests {
+		got, err := s.CalculateFee(account.ID, test.amount, test.category)
+		if err != nil {
+			t.Errorf("CalculateFee(): error = %v", err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("CalculateFee(%v, %v): got = %v, want = %v", test.amount, test.category, got, test.want)
+		}
+	}
+}
+
+func Test_Pay_fee(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	revenue, err := s.addRevenueAccount()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetFeeSchedule([]types.FeeRule{{Category: "auto", Kind: types.FeePercent, Percent: 100}})
+	if err != nil {
+		t.Errorf("SetFeeSchedule(): error = %v", err)
+		return
+	}
+
+	balance := account.Balance
+	payment, err := s.Pay(account.ID, 1_000_00, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	if payment.Fee != 10_00 || account.Balance != balance-1_010_00 || revenue.Balance != 10_00 {
+		t.Errorf("Pay(): fee not charged, payment = %v, account = %v, revenue = %v", payment, account, revenue)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+
+	if account.Balance != balance || revenue.Balance != 0 {
+		t.Errorf("Reject(): fee not returned, account = %v, revenue = %v", account, revenue)
+		return
+	}
+}
+
+func Test_Pay_feeFail(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetFeeSchedule([]types.FeeRule{{Kind: types.FeeFlat, Flat: 1_00}})
+	if err != nil {
+		t.Errorf("SetFeeSchedule(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 100, "auto")
+	if err != ErrNoRevenueAccount {
+		t.Errorf("Pay(): must return ErrNoRevenueAccount, returned = %v", err)
+		return
+	}
+
+	_, err = s.addRevenueAccount()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, account.Balance, "auto")
+	if err != ErrNotEnoughBalance {
+		t.Errorf("Pay(): must return ErrNotEnoughBalance, returned = %v", err)
+		return
+	}
+
+	err = s.SetFeeSchedule([]types.FeeRule{{Kind: "UNKNOWN"}})
+	if err != ErrInvalidFeeRule {
+		t.Errorf("SetFeeSchedule(): must return ErrInvalidFeeRule, returned = %v", err)
+		return
+	}
+}
+
+func Test_Reject_feeAccount(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	revenue, err := s.addRevenueAccount()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetFeeSchedule([]types.FeeRule{{Kind: types.FeeFlat, Flat: 1_00}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	first, err := s.Pay(account.ID, 10_00, "auto")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	second, err := s.Pay(account.ID, 10_00, "auto")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	next, err := s.RegisterAccount("992900000001")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetRevenueAccount(next.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Reject(first.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+	if revenue.Balance != 1_00 || next.Balance != 0 || first.FeeAccountID != revenue.ID {
+		t.Errorf("Reject(): fee reversed from wrong account, revenue = %v, next = %v", revenue, next)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+
+	err = imported.Reject(second.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+	got, err := imported.FindAccountByID(revenue.ID)
+	if err != nil || got.Balance != 0 {
+		t.Errorf("Reject(): revenue = %v, error = %v", got, err)
+		return
+	}
+}
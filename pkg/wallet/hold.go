@@ -0,0 +1,149 @@
+package wallet
+
+import (
+	"errors"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+// DefaultHoldTTL lifetime of a hold when Authorize is called without ttl
+const DefaultHoldTTL = 7 * 24 * time.Hour
+
+var ErrHoldNotFound = errors.New("hold not found")
+var ErrHoldNotActive = errors.New("hold is not active")
+var ErrCaptureExceedsHold = errors.New("capture amount exceeds hold")
+
+// Authorize reserves amount on the account available balance for ttl
+func (s *Service) Authorize(accountID int64, amount types.Money, ttl time.Duration) (*types.Hold, error) {
+	if amount <= 0 {
+		return nil, ErrAmountmustBePositive
+	}
+
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkAccountActive(account)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ExpireHolds()
+	if account.Available() < amount {
+		return nil, ErrNotEnoughBalance
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultHoldTTL
+	}
+
+	now := s.now()
+	hold := &types.Hold{
+		ID:        uuid.New().String(),
+		AccountID: account.ID,
+		Amount:    amount,
+		Status:    types.HoldStatusActive,
+		Created:   now,
+		Expires:   now.Add(ttl),
+	}
+	account.Held += amount
+	s.holds = append(s.holds, hold)
+	return hold, nil
+}
+
+func (s *Service) FindHoldByID(holdID string) (*types.Hold, error) {
+	for _, hold := range s.holds {
+		if hold.ID == holdID {
+			return hold, nil
+		}
+	}
+	return nil, ErrHoldNotFound
+}
+
+// Capture settles all or part of the hold into a payment, the rest of the
+// hold is released.
+func (s *Service) Capture(holdID string, amount types.Money, category types.PaymentCategory) (*types.Payment, error) {
+	hold, err := s.activeHold(holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount <= 0 {
+		return nil, ErrAmountmustBePositive
+	}
+	if amount > hold.Amount {
+		return nil, ErrCaptureExceedsHold
+	}
+
+	account, err := s.FindAccountByID(hold.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the hold leaves the active state before Pay, so that expiring holds
+	// inside Pay cannot release it once more
+	account.Held -= hold.Amount
+	hold.Status = types.HoldStatusCaptured
+	payment, err := s.Pay(account.ID, amount, category)
+	if err != nil {
+		account.Held += hold.Amount
+		hold.Status = types.HoldStatusActive
+		return nil, err
+	}
+
+	hold.PaymentID = payment.ID
+	return payment, nil
+}
+
+// Void releases the hold without a payment
+func (s *Service) Void(holdID string) error {
+	hold, err := s.activeHold(holdID)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.FindAccountByID(hold.AccountID)
+	if err != nil {
+		return err
+	}
+
+	account.Held -= hold.Amount
+	hold.Status = types.HoldStatusVoided
+	return nil
+}
+
+// ExpireHolds releases all active holds whose lifetime is over and returns
+// their count.
+func (s *Service) ExpireHolds() int {
+	now := s.now()
+	count := 0
+	for _, hold := range s.holds {
+		if hold.Status != types.HoldStatusActive || now.Before(hold.Expires) {
+			continue
+		}
+
+		account, err := s.FindAccountByID(hold.AccountID)
+		if err == nil {
+			account.Held -= hold.Amount
+		}
+		hold.Status = types.HoldStatusExpired
+		count++
+	}
+	return count
+}
+
+func (s *Service) activeHold(holdID string) (*types.Hold, error) {
+	hold, err := s.FindHoldByID(holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ExpireHolds()
+	if hold.Status != types.HoldStatusActive {
+		return nil, ErrHoldNotActive
+	}
+	return hold, nil
+}
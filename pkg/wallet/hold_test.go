@@ -0,0 +1,192 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_Authorize_success(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	available := account.Available()
+	hold, err := s.Authorize(account.ID, available-100, time.Hour)
+	if err != nil {
+		t.Errorf("Authorize(): error = %v", err)
+		return
+	}
+
+	if account.Available() != 100 || account.Held != hold.Amount {
+		t.Errorf("Authorize(): wrong balances, account = %v", account)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 200, "auto")
+	if err != ErrNotEnoughBalance {
+		t.Errorf("Pay(): must return ErrNotEnoughBalance, returned = %v", err)
+		return
+	}
+}
+
+func Test_Capture_success(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	balance := account.Balance
+	hold, err := s.Authorize(account.ID, 500_00, time.Hour)
+	if err != nil {
+		t.Errorf("Authorize(): error = %v", err)
+		return
+	}
+
+	payment, err := s.Capture(hold.ID, 300_00, "auto")
+	if err != nil {
+		t.Errorf("Capture(): error = %v", err)
+		return
+	}
+
+	if payment.Amount != 300_00 || hold.Status != types.HoldStatusCaptured || hold.PaymentID != payment.ID {
+		t.Errorf("Capture(): wrong result, hold = %v, payment = %v", hold, payment)
+		return
+	}
+
+	if account.Held != 0 || account.Balance != balance-300_00 {
+		t.Errorf("Capture(): wrong balances, account = %v", account)
+		return
+	}
+
+	_, err = s.Capture(hold.ID, 100, "auto")
+	if err != ErrHoldNotActive {
+		t.Errorf("Capture(): must return ErrHoldNotActive, returned = %v", err)
+		return
+	}
+}
+
+func Test_Capture_fail(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	hold, err := s.Authorize(account.ID, 500_00, time.Hour)
+	if err != nil {
+		t.Errorf("Authorize(): error = %v", err)
+		return
+	}
+
+	_, err = s.Capture(hold.ID, 600_00, "auto")
+	if err != ErrCaptureExceedsHold {
+		t.Errorf("Capture(): must return ErrCaptureExceedsHold, returned = %v", err)
+		return
+	}
+
+	if account.Held != hold.Amount {
+		t.Errorf("Capture(): hold released on error, account = %v", account)
+		return
+	}
+}
+
+func Test_Void_success(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	hold, err := s.Authorize(account.ID, 500_00, time.Hour)
+	if err != nil {
+		t.Errorf("Authorize(): error = %v", err)
+		return
+	}
+
+	err = s.Void(hold.ID)
+	if err != nil {
+		t.Errorf("Void(): error = %v", err)
+		return
+	}
+
+	if account.Held != 0 || hold.Status != types.HoldStatusVoided {
+		t.Errorf("Void(): hold not released, account = %v, hold = %v", account, hold)
+		return
+	}
+}
+
+func Test_ExpireHolds(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)}
+	s.SetClock(clock)
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	hold, err := s.Authorize(account.ID, 500_00, time.Hour)
+	if err != nil {
+		t.Errorf("Authorize(): error = %v", err)
+		return
+	}
+
+	clock.advance(2 * time.Hour)
+	if count := s.ExpireHolds(); count != 1 {
+		t.Errorf("ExpireHolds(): wrong count = %v", count)
+		return
+	}
+
+	if account.Held != 0 || hold.Status != types.HoldStatusExpired {
+		t.Errorf("ExpireHolds(): hold not released, account = %v, hold = %v", account, hold)
+		return
+	}
+}
+
+// tickingClock moves forward by tick on every read
+type tickingClock struct {
+	now  time.Time
+	tick time.Duration
+}
+
+func (c *tickingClock) Now() time.Time {
+	now := c.now
+	c.now = c.now.Add(c.tick)
+	return now
+}
+
+func Test_Capture_expiring(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	s.SetClock(&tickingClock{now: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC), tick: time.Minute})
+
+	// the hold expires after the capture checks it and before Pay does
+	hold, err := s.Authorize(account.ID, 100_00, 90*time.Second)
+	if err != nil {
+		t.Errorf("Authorize(): error = %v", err)
+		return
+	}
+
+	payment, err := s.Capture(hold.ID, 50_00, "auto")
+	if err != nil {
+		t.Errorf("Capture(): error = %v", err)
+		return
+	}
+	if account.Held != 0 || hold.Status != types.HoldStatusCaptured || hold.PaymentID != payment.ID {
+		t.Errorf("Capture(): hold released twice, account = %v, hold = %v", account, hold)
+		return
+	}
+}
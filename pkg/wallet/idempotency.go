@@ -0,0 +1,122 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+// DefaultIdempotencyWindow how long idempotency keys are retained by default
+const DefaultIdempotencyWindow = 24 * time.Hour
+
+var ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+var ErrIdempotencyKeyReused = errors.New("idempotency key used for another request")
+
+// SetIdempotencyWindow sets how long idempotency keys are retained
+func (s *Service) SetIdempotencyWindow(window time.Duration) {
+	s.idempotencyWindow = window
+}
+
+// PayWithKey works like Pay, but a repeated call with the same key returns the
+// original payment instead of paying again. Empty key disables the check.
+func (s *Service) PayWithKey(key string, accountID int64, amount types.Money, category types.PaymentCategory) (*types.Payment, error) {
+	request := fmt.Sprintf("pay/%d/%d/%s", accountID, amount, category)
+	return s.idempotentPayment(key, request, func() (*types.Payment, error) {
+		return s.Pay(accountID, amount, category)
+	})
+}
+
+// PayFromFavoriteWithKey works like PayFromFavorite with an idempotency key
+func (s *Service) PayFromFavoriteWithKey(key string, favoriteID string) (*types.Payment, error) {
+	request := fmt.Sprintf("favorite/%s", favoriteID)
+	return s.idempotentPayment(key, request, func() (*types.Payment, error) {
+		return s.PayFromFavorite(favoriteID)
+	})
+}
+
+// DepositWithKey works like Deposit with an idempotency key
+func (s *Service) DepositWithKey(key string, accountID int64, amount types.Money) error {
+	if key == "" {
+		return s.Deposit(accountID, amount)
+	}
+
+	request := fmt.Sprintf("deposit/%d/%d", accountID, amount)
+	record, err := s.findIdempotencyRecord(key, request)
+	if err != nil {
+		return err
+	}
+	if record != nil {
+		return nil
+	}
+
+	err = s.Deposit(accountID, amount)
+	if err != nil {
+		return err
+	}
+	s.rememberIdempotencyRecord(key, request, "")
+	return nil
+}
+
+func (s *Service) idempotentPayment(key string, request string, pay func() (*types.Payment, error)) (*types.Payment, error) {
+	if key == "" {
+		return pay()
+	}
+
+	record, err := s.findIdempotencyRecord(key, request)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil {
+		return s.FindPaymentByID(record.PaymentID)
+	}
+
+	payment, err := pay()
+	if err != nil {
+		return nil, err
+	}
+	s.rememberIdempotencyRecord(key, request, payment.ID)
+	return payment, nil
+}
+
+func (s *Service) findIdempotencyRecord(key string, request string) (*types.IdempotencyRecord, error) {
+	if strings.ContainsAny(key, ";\n") {
+		return nil, ErrInvalidIdempotencyKey
+	}
+
+	window := s.idempotencyWindow
+	if window <= 0 {
+		window = DefaultIdempotencyWindow
+	}
+
+	now := s.now()
+	records := s.idempotency[:0]
+	for _, record := range s.idempotency {
+		if now.Sub(record.Created) < window {
+			records = append(records, record)
+		}
+	}
+	s.idempotency = records
+
+	for _, record := range s.idempotency {
+		if record.Key != key {
+			continue
+		}
+		if record.Request != request {
+			return nil, ErrIdempotencyKeyReused
+		}
+		return record, nil
+	}
+	return nil, nil
+}
+
+func (s *Service) rememberIdempotencyRecord(key string, request string, paymentID string) {
+	s.idempotency = append(s.idempotency, &types.IdempotencyRecord{
+		Key:       key,
+		Request:   request,
+		PaymentID: paymentID,
+		Created:   s.now(),
+	})
+}
@@ -0,0 +1,137 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_PayWithKey_success(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	balance := account.Balance
+	payment, err := s.PayWithKey("order-1", account.ID, 100_00, "auto")
+	if err != nil {
+		t.Errorf("PayWithKey(): error = %v", err)
+		return
+	}
+
+	repeated, err := s.PayWithKey("order-1", account.ID, 100_00, "auto")
+	if err != nil {
+		t.Errorf("PayWithKey(): error = %v", err)
+		return
+	}
+
+	if repeated.ID != payment.ID {
+		t.Errorf("PayWithKey(): new payment created, payment = %v, repeated = %v", payment, repeated)
+		return
+	}
+
+	if account.Balance != balance-100_00 {
+		t.Errorf("PayWithKey(): balance debited twice, account = %v", account)
+		return
+	}
+}
+
+func Test_PayWithKey_fail(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.PayWithKey("order-1", account.ID, 100_00, "auto")
+	if err != nil {
+		t.Errorf("PayWithKey(): error = %v", err)
+		return
+	}
+
+	_, err = s.PayWithKey("order-1", account.ID, 200_00, "auto")
+	if err != ErrIdempotencyKeyReused {
+		t.Errorf("PayWithKey(): must return ErrIdempotencyKeyReused, returned = %v", err)
+		return
+	}
+}
+
+func Test_DepositWithKey_window(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)}
+	s.SetClock(clock)
+	s.SetIdempotencyWindow(time.Hour)
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	balance := account.Balance
+	for i := 0; i < 2; i++ {
+		err = s.DepositWithKey("topup-1", account.ID, 100_00)
+		if err != nil {
+			t.Errorf("DepositWithKey(): error = %v", err)
+			return
+		}
+	}
+
+	if account.Balance != balance+100_00 {
+		t.Errorf("DepositWithKey(): deposited twice, account = %v", account)
+		return
+	}
+
+	clock.advance(2 * time.Hour)
+	err = s.DepositWithKey("topup-1", account.ID, 100_00)
+	if err != nil {
+		t.Errorf("DepositWithKey(): error = %v", err)
+		return
+	}
+
+	if account.Balance != balance+200_00 {
+		t.Errorf("DepositWithKey(): key not expired, account = %v", account)
+		return
+	}
+}
+
+func Test_Import_idempotency(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.PayWithKey("order-1", account.ID, 100_00, "auto")
+	if err != nil {
+		t.Errorf("PayWithKey(): error = %v", err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+
+	repeated, err := imported.PayWithKey("order-1", account.ID, 100_00, "auto")
+	if err != nil {
+		t.Errorf("PayWithKey(): error = %v", err)
+		return
+	}
+
+	if repeated.ID != payment.ID {
+		t.Errorf("PayWithKey(): key not imported, payment = %v, repeated = %v", payment, repeated)
+		return
+	}
+}
@@ -0,0 +1,168 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+var ErrLimitExceeded = errors.New("spending limit exceeded")
+var ErrTierLimitExceeded = errors.New("tier limit exceeded")
+
+// LimitKind which spending limit was hit
+type LimitKind string
+
+// Spending limit kinds
+const (
+	LimitPerTransaction LimitKind = "transaction"
+	LimitDaily          LimitKind = "daily"
+	LimitMonthly        LimitKind = "monthly"
+	LimitCategory       LimitKind = "category"
+	LimitTierBalance    LimitKind = "tier balance"
+	LimitTierTurnover   LimitKind = "tier turnover"
+)
+
+// LimitError returned by payments that exceed a spending limit, it matches
+// ErrLimitExceeded with errors.Is, or ErrTierLimitExceeded for tier limits
+type LimitError struct {
+	Kind      LimitKind
+	Category  types.PaymentCategory
+	Limit     types.Money
+	Remaining types.Money
+}
+
+func (e *LimitError) Error() string {
+	if e.Kind == LimitCategory {
+		return fmt.Sprintf("%s: %s limit for %q is %d, remaining %d", e.Unwrap(), e.Kind, e.Category, e.Limit, e.Remaining)
+	}
+	return fmt.Sprintf("%s: %s limit is %d, remaining %d", e.Unwrap(), e.Kind, e.Limit, e.Remaining)
+}
+
+func (e *LimitError) Unwrap() error {
+	if e.Kind == LimitTierBalance || e.Kind == LimitTierTurnover {
+		return ErrTierLimitExceeded
+	}
+	return ErrLimitExceeded
+}
+
+// SetSpendingLimits replaces spending limits of the account
+func (s *Service) SetSpendingLimits(accountID int64, limits types.SpendingLimits) error {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	if limits.PerTransaction < 0 || limits.Daily < 0 || limits.Monthly < 0 {
+		return ErrAmountmustBePositive
+	}
+	categories := map[types.PaymentCategory]types.Money{}
+	for category, limit := range limits.Categories {
+		if limit < 0 {
+			return ErrAmountmustBePositive
+		}
+		category, err = s.ResolveCategory(string(category))
+		if err != nil {
+			return err
+		}
+		categories[category] = limit
+	}
+	limits.Categories = categories
+
+	if s.limits == nil {
+		s.limits = map[int64]types.SpendingLimits{}
+	}
+	s.limits[account.ID] = limits
+	return nil
+}
+
+// SpendingLimits returns spending limits of the account
+func (s *Service) SpendingLimits(accountID int64) (types.SpendingLimits, error) {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return types.SpendingLimits{}, err
+	}
+	return s.limits[account.ID], nil
+}
+
+// checkSpendingLimits checks payment against account limits, pending are
+// payments of a batch validated before it and not made yet
+func (s *Service) checkSpendingLimits(account *types.Account, amount types.Money, category types.PaymentCategory, pending []types.BatchItem) error {
+	limits, ok := s.limits[account.ID]
+	if !ok {
+		return nil
+	}
+
+	if limits.PerTransaction > 0 && amount > limits.PerTransaction {
+		return &LimitError{Kind: LimitPerTransaction, Limit: limits.PerTransaction, Remaining: limits.PerTransaction}
+	}
+
+	now := s.now()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monthStart := startOfMonth(now)
+
+	if limits.Daily > 0 {
+		spent := s.spent(account.ID, dayStart, "") + s.pendingSpent(pending, account.ID, "")
+		if spent+amount > limits.Daily {
+			return &LimitError{Kind: LimitDaily, Limit: limits.Daily, Remaining: remaining(limits.Daily, spent)}
+		}
+	}
+
+	if limits.Monthly > 0 {
+		spent := s.spent(account.ID, monthStart, "") + s.pendingSpent(pending, account.ID, "")
+		if spent+amount > limits.Monthly {
+			return &LimitError{Kind: LimitMonthly, Limit: limits.Monthly, Remaining: remaining(limits.Monthly, spent)}
+		}
+	}
+
+	// a category limit covers payments in its subcategories too
+	for _, ancestor := range s.categoryPath(category) {
+		limit := limits.Categories[ancestor]
+		if limit <= 0 {
+			continue
+		}
+		spent := s.spent(account.ID, monthStart, ancestor) + s.pendingSpent(pending, account.ID, ancestor)
+		if spent+amount > limit {
+			return &LimitError{Kind: LimitCategory, Category: ancestor, Limit: limit, Remaining: remaining(limit, spent)}
+		}
+	}
+	return nil
+}
+
+// spent sums not rejected and not refunded payments of the account since the
+// time, optionally only in one category and its subcategories
+func (s *Service) spent(accountID int64, since time.Time, category types.PaymentCategory) types.Money {
+	spent := types.Money(0)
+	for _, payment := range s.payments {
+		if payment.AccountID != accountID || payment.Status == types.PaymentStatusFail {
+			continue
+		}
+		if payment.Created.Before(since) || (category != "" && !s.InCategory(payment.Category, category)) {
+			continue
+		}
+		spent += payment.Amount - payment.Refunded
+	}
+	return spent
+}
+
+func (s *Service) pendingSpent(pending []types.BatchItem, accountID int64, category types.PaymentCategory) types.Money {
+	spent := types.Money(0)
+	for _, item := range pending {
+		if item.AccountID == accountID && (category == "" || s.InCategory(item.Category, category)) {
+			spent += item.Amount
+		}
+	}
+	return spent
+}
+
+func startOfMonth(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+}
+
+func remaining(limit types.Money, spent types.Money) types.Money {
+	if spent >= limit {
+		return 0
+	}
+	return limit - spent
+}
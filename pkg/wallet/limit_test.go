@@ -0,0 +1,104 @@
+package wallet
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_SpendingLimits_transaction(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetSpendingLimits(account.ID, types.SpendingLimits{PerTransaction: 500_00})
+	if err != nil {
+		t.Errorf("SetSpendingLimits(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 600_00, "auto")
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Pay(): must return ErrLimitExceeded, returned = %v", err)
+		return
+	}
+
+	limitErr := &LimitError{}
+	if !errors.As(err, &limitErr) || limitErr.Kind != LimitPerTransaction {
+		t.Errorf("Pay(): wrong limit error = %v", err)
+		return
+	}
+}
+
+func Test_SpendingLimits_daily(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)}
+	s.SetClock(clock)
+	account, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetSpendingLimits(account.ID, types.SpendingLimits{Daily: 1_500_00, Monthly: 2_500_00})
+	if err != nil {
+		t.Errorf("SetSpendingLimits(): error = %v", err)
+		return
+	}
+
+	_, err = s.Repeat(payments[0].ID)
+	limitErr := &LimitError{}
+	if !errors.As(err, &limitErr) || limitErr.Kind != LimitDaily || limitErr.Remaining != 500_00 {
+		t.Errorf("Repeat(): wrong limit error = %v", err)
+		return
+	}
+
+	clock.advance(24 * time.Hour)
+	_, err = s.Repeat(payments[0].ID)
+	if err != nil {
+		t.Errorf("Repeat(): error = %v", err)
+		return
+	}
+
+	clock.advance(24 * time.Hour)
+	_, err = s.Pay(account.ID, 600_00, "mobile")
+	if !errors.As(err, &limitErr) || limitErr.Kind != LimitMonthly || limitErr.Remaining != 500_00 {
+		t.Errorf("Pay(): wrong limit error = %v", err)
+		return
+	}
+}
+
+func Test_SpendingLimits_category(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetSpendingLimits(account.ID, types.SpendingLimits{
+		Categories: map[types.PaymentCategory]types.Money{"auto": 1_200_00},
+	})
+	if err != nil {
+		t.Errorf("SetSpendingLimits(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 300_00, "auto")
+	limitErr := &LimitError{}
+	if !errors.As(err, &limitErr) || limitErr.Kind != LimitCategory || limitErr.Remaining != 200_00 {
+		t.Errorf("Pay(): wrong limit error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 300_00, "mobile")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+}
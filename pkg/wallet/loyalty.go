@@ -0,0 +1,185 @@
+package wallet
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/fm2901/wallet/pkg/money"
+	"github.com/fm2901/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCashbackRule = errors.New("invalid cashback rule")
+
+// SetCashbackRules replaces cashback rules, a rule for the payment category or
+// its closest parent wins over the default rule with empty category
+func (s *Service) SetCashbackRules(rules []types.CashbackRule) error {
+	rules = append([]types.CashbackRule{}, rules...)
+	for i, rule := range rules {
+		if rule.Percent < 0 || rule.Max < 0 || rule.PointsPerUnit < 0 {
+			return ErrInvalidCashbackRule
+		}
+		if rule.Category != "" {
+			category, err := s.ResolveCategory(string(rule.Category))
+			if err != nil {
+				return err
+			}
+			rules[i].Category = category
+		}
+	}
+
+	s.cashbackRules = rules
+	return nil
+}
+
+// PointsBalance returns loyalty points of the account
+func (s *Service) PointsBalance(accountID int64) (int64, error) {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return 0, err
+	}
+
+	points := int64(0)
+	for _, entry := range s.loyalty {
+		if entry.AccountID == account.ID {
+			points += entry.Points
+		}
+	}
+	return points, nil
+}
+
+// LoyaltyHistory returns cashback and points accruals and reversals of the account
+func (s *Service) LoyaltyHistory(accountID int64) ([]types.LoyaltyEntry, error) {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := []types.LoyaltyEntry{}
+	for _, entry := range s.loyalty {
+		if entry.AccountID == account.ID {
+			history = append(history, *entry)
+		}
+	}
+	return history, nil
+}
+
+func (s *Service) cashbackRule(category types.PaymentCategory) *types.CashbackRule {
+	for _, ancestor := range s.categoryPath(category) {
+		for i := range s.cashbackRules {
+			if s.cashbackRules[i].Category == ancestor {
+				return &s.cashbackRules[i]
+			}
+		}
+	}
+	for i := range s.cashbackRules {
+		if s.cashbackRules[i].Category == "" {
+			return &s.cashbackRules[i]
+		}
+	}
+	return nil
+}
+
+// loyaltyAccrual returns cashback and points earned by a payment made from
+// the account. Cashback is capped so that balance, the account balance after
+// the payment, stays within the tier limit.
+func (s *Service) loyaltyAccrual(account *types.Account, amount types.Money, category types.PaymentCategory, balance types.Money) (types.Money, int64, error) {
+	rule := s.cashbackRule(category)
+	if rule == nil {
+		return 0, 0, nil
+	}
+
+	cashback, err := money.Percent(amount, rule.Percent)
+	if err != nil {
+		return 0, 0, err
+	}
+	if rule.Max > 0 && cashback > rule.Max {
+		cashback = rule.Max
+	}
+	limits := s.TierLimits(account.Tier)
+	if limits.MaxBalance > 0 && cashback > limits.MaxBalance-balance {
+		cashback = remaining(limits.MaxBalance, balance)
+	}
+	_, err = money.Add(balance, cashback)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	unit := types.Money(1)
+	for i := 0; i < account.Currency.Exponent(); i++ {
+		unit *= 10
+	}
+	points, err := money.Mul(amount/unit, rule.PointsPerUnit)
+	if err != nil {
+		return 0, 0, err
+	}
+	return cashback, int64(points), nil
+}
+
+// accrueLoyalty credits cashback and points calculated by loyaltyAccrual for
+// the payment
+func (s *Service) accrueLoyalty(account *types.Account, payment *types.Payment, cashback types.Money, points int64) {
+	if cashback == 0 && points == 0 {
+		return
+	}
+	account.Balance += cashback
+	s.addLoyaltyEntry(account.ID, payment.ID, cashback, points)
+}
+
+// loyaltyReversal returns cashback and points to add to the account, zero or
+// negative, so that only the part proportional to kept, the amount of the
+// payment left after a refund or rejection, stays accrued
+func (s *Service) loyaltyReversal(payment *types.Payment, kept types.Money) (types.Money, int64) {
+	accrued := types.Money(0)
+	net := types.Money(0)
+	accruedPoints := int64(0)
+	netPoints := int64(0)
+	for _, entry := range s.loyalty {
+		if entry.PaymentID != payment.ID {
+			continue
+		}
+		if entry.Cashback > 0 || entry.Points > 0 {
+			accrued += entry.Cashback
+			accruedPoints += entry.Points
+		}
+		net += entry.Cashback
+		netPoints += entry.Points
+	}
+	if accrued == 0 && accruedPoints == 0 {
+		return 0, 0
+	}
+
+	cashback := types.Money(proportion(int64(accrued), int64(kept), int64(payment.Amount)))
+	points := proportion(accruedPoints, int64(kept), int64(payment.Amount))
+	return cashback - net, points - netPoints
+}
+
+// reverseLoyalty applies reversal computed by loyaltyReversal
+func (s *Service) reverseLoyalty(account *types.Account, payment *types.Payment, cashback types.Money, points int64) {
+	if cashback == 0 && points == 0 {
+		return
+	}
+	account.Balance += cashback
+	s.addLoyaltyEntry(account.ID, payment.ID, cashback, points)
+}
+
+func (s *Service) addLoyaltyEntry(accountID int64, paymentID string, cashback types.Money, points int64) {
+	s.loyalty = append(s.loyalty, &types.LoyaltyEntry{
+		ID:        uuid.New().String(),
+		AccountID: accountID,
+		PaymentID: paymentID,
+		Cashback:  cashback,
+		Points:    points,
+		Created:   s.now(),
+	})
+}
+
+// proportion returns value * part / total without overflow, rounded down
+func proportion(value int64, part int64, total int64) int64 {
+	if total == 0 {
+		return 0
+	}
+	result := new(big.Int).Mul(big.NewInt(value), big.NewInt(part))
+	result.Quo(result, big.NewInt(total))
+	return result.Int64()
+}
@@ -0,0 +1,189 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_Pay_cashback(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetCashbackRules([]types.CashbackRule{
+		{PointsPerUnit: 1},
+		{Category: "pharmacy", Percent: 500, Max: 100_00, PointsPerUnit: 2},
+	})
+	if err != nil {
+		t.Errorf("SetCashbackRules(): error = %v", err)
+		return
+	}
+
+	balance := account.Balance
+	payment, err := s.Pay(account.ID, 200_00, "pharmacy")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	if account.Balance != balance-200_00+10_00 {
+		t.Errorf("Pay(): cashback not credited, account = %v", account)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 50_00, "mobile")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	points, err := s.PointsBalance(account.ID)
+	if err != nil || points != 450 {
+		t.Errorf("PointsBalance(): got = %v, error = %v", points, err)
+		return
+	}
+
+	_, err = s.Refund(payment.ID, 50_00)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+
+	if account.Balance != balance-200_00+10_00-50_00+50_00-2_50 {
+		t.Errorf("Refund(): cashback not reversed, account = %v", account)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+
+	if account.Balance != balance-50_00 {
+		t.Errorf("Reject(): cashback not reversed, account = %v", account)
+		return
+	}
+
+	points, err = s.PointsBalance(account.ID)
+	if err != nil || points != 50 {
+		t.Errorf("PointsBalance(): got = %v, error = %v", points, err)
+		return
+	}
+
+	history, err := s.LoyaltyHistory(account.ID)
+	if err != nil || len(history) != 4 {
+		t.Errorf("LoyaltyHistory(): got = %v, error = %v", history, err)
+		return
+	}
+}
+
+func Test_SetCashbackRules_fail(t *testing.T) {
+	s := newTestService()
+	err := s.SetCashbackRules([]types.CashbackRule{{Percent: -1}})
+	if err != ErrInvalidCashbackRule {
+		t.Errorf("SetCashbackRules(): must return ErrInvalidCashbackRule, returned = %v", err)
+		return
+	}
+}
+
+func Test_Pay_cashbackTierCap(t *testing.T) {
+	s := newTestService()
+	account, err := s.RegisterAccount("992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.Deposit(account.ID, 3_000_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetCashbackRules([]types.CashbackRule{{Percent: 5_000}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetTierLimits(types.TierAnonymous, types.TierLimits{MaxBalance: 2_850_00})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 100_00, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+	_, err = s.Pay(account.ID, 100_00, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	history, err := s.LoyaltyHistory(account.ID)
+	if err != nil || len(history) != 1 || history[0].Cashback != 50_00 {
+		t.Errorf("LoyaltyHistory(): got = %v, error = %v", history, err)
+		return
+	}
+	if account.Balance != 2_850_00 {
+		t.Errorf("Pay(): cashback over tier limit, balance = %v", account.Balance)
+		return
+	}
+}
+
+func Test_Reject_loyaltyUnchangedOnError(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.addRevenueAccount()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetFeeSchedule([]types.FeeRule{{Kind: types.FeeFlat, Flat: 1_00}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetCashbackRules([]types.CashbackRule{{Percent: 100}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.Pay(account.ID, 100_00, "food")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	balance := account.Balance
+
+	// accounts and merchants involved are gone, so nothing may be reversed
+	payment.FeeAccountID = 404
+	err = s.Reject(payment.ID)
+	if err != ErrAccountNotFound {
+		t.Errorf("Reject(): must return ErrAccountNotFound, returned = %v", err)
+		return
+	}
+	payment.MerchantID = 404
+	_, err = s.Refund(payment.ID, 50_00)
+	if err != ErrMerchantNotFound {
+		t.Errorf("Refund(): must return ErrMerchantNotFound, returned = %v", err)
+		return
+	}
+
+	history, err := s.LoyaltyHistory(account.ID)
+	if err != nil || account.Balance != balance || payment.Status != types.PaymentStatusInProgress || payment.Refunded != 0 || len(history) != 1 {
+		t.Errorf("Reject(): half applied, account = %v, payment = %v, history = %v", account, payment, history)
+		return
+	}
+}
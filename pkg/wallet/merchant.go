@@ -0,0 +1,123 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+var ErrMerchantNotFound = errors.New("merchant not found")
+var ErrInvalidMerchant = errors.New("invalid merchant")
+
+// RegisterMerchant adds merchant accepting payments of category in currency,
+// empty currency means DefaultCurrency
+func (s *Service) RegisterMerchant(name string, category types.PaymentCategory, currency types.Currency) (*types.Merchant, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || category == "" {
+		return nil, ErrInvalidMerchant
+	}
+
+	category, err := s.ResolveCategory(string(category))
+	if err != nil {
+		return nil, err
+	}
+
+	if currency == "" {
+		currency = types.DefaultCurrency
+	}
+	if !currency.Valid() {
+		return nil, ErrUnknownCurrency
+	}
+
+	s.nextMerchantID++
+	merchant := &types.Merchant{
+		ID:       s.nextMerchantID,
+		Name:     name,
+		Category: category,
+		Currency: currency,
+	}
+	s.merchants = append(s.merchants, merchant)
+	return merchant, nil
+}
+
+func (s *Service) FindMerchantByID(merchantID int64) (*types.Merchant, error) {
+	for _, merchant := range s.merchants {
+		if merchant.ID == merchantID {
+			return merchant, nil
+		}
+	}
+	return nil, ErrMerchantNotFound
+}
+
+// PayMerchant pays merchant in its category and credits the merchant balance
+func (s *Service) PayMerchant(accountID int64, merchantID int64, amount types.Money) (*types.Payment, error) {
+	merchant, err := s.FindMerchantByID(merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Currency != merchant.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	payment, err := s.Pay(account.ID, amount, merchant.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	payment.MerchantID = merchant.ID
+	merchant.Balance += amount
+	return payment, nil
+}
+
+// MerchantTurnover returns amount paid to merchant from from until to without
+// rejected payments and refunds. Zero to means no upper bound.
+func (s *Service) MerchantTurnover(merchantID int64, from time.Time, to time.Time) (types.Money, error) {
+	merchant, err := s.FindMerchantByID(merchantID)
+	if err != nil {
+		return 0, err
+	}
+
+	turnover := types.Money(0)
+	for _, payment := range s.payments {
+		if payment.MerchantID != merchant.ID || payment.Status == types.PaymentStatusFail {
+			continue
+		}
+		if payment.Created.Before(from) || (!to.IsZero() && !payment.Created.Before(to)) {
+			continue
+		}
+		turnover += payment.Amount - payment.Refunded
+	}
+	return turnover, nil
+}
+
+// MerchantPayments returns payments addressed to merchant
+func (s *Service) MerchantPayments(merchantID int64) ([]types.Payment, error) {
+	merchant, err := s.FindMerchantByID(merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	payments := []types.Payment{}
+	for _, payment := range s.payments {
+		if payment.MerchantID == merchant.ID {
+			payments = append(payments, *payment)
+		}
+	}
+	return payments, nil
+}
+
+// paymentMerchant returns merchant paid by the payment, nil when the payment
+// is not addressed to a merchant
+func (s *Service) paymentMerchant(payment *types.Payment) (*types.Merchant, error) {
+	if payment.MerchantID == 0 {
+		return nil, nil
+	}
+	return s.FindMerchantByID(payment.MerchantID)
+}
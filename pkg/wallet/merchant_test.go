@@ -0,0 +1,216 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_PayMerchant_success(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)}
+	s.SetClock(clock)
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	merchant, err := s.RegisterMerchant(" Car Shop ", "auto", "")
+	if err != nil {
+		t.Errorf("RegisterMerchant(): error = %v", err)
+		return
+	}
+
+	first, err := s.PayMerchant(account.ID, merchant.ID, 300_00)
+	if err != nil {
+		t.Errorf("PayMerchant(): error = %v", err)
+		return
+	}
+	clock.advance(24 * time.Hour)
+	second, err := s.PayMerchant(account.ID, merchant.ID, 200_00)
+	if err != nil {
+		t.Errorf("PayMerchant(): error = %v", err)
+		return
+	}
+
+	if first.MerchantID != merchant.ID || first.Category != "auto" || merchant.Name != "Car Shop" || merchant.Balance != 500_00 {
+		t.Errorf("PayMerchant(): wrong payment = %v, merchant = %v", first, merchant)
+		return
+	}
+
+	_, err = s.Refund(second.ID, 50_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.Reject(first.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if merchant.Balance != 150_00 {
+		t.Errorf("Reject(): wrong merchant balance = %v", merchant.Balance)
+		return
+	}
+
+	turnover, err := s.MerchantTurnover(merchant.ID, time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), time.Time{})
+	if err != nil || turnover != 150_00 {
+		t.Errorf("MerchantTurnover(): turnover = %v, error = %v", turnover, err)
+		return
+	}
+}
+
+func Test_PayMerchant_fail(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.RegisterMerchant("  ", "auto", "")
+	if err != ErrInvalidMerchant {
+		t.Errorf("RegisterMerchant(): must return ErrInvalidMerchant, returned = %v", err)
+		return
+	}
+
+	_, err = s.PayMerchant(account.ID, 1, 100_00)
+	if err != ErrMerchantNotFound {
+		t.Errorf("PayMerchant(): must return ErrMerchantNotFound, returned = %v", err)
+		return
+	}
+
+	merchant, err := s.RegisterMerchant("Duty free", "shop", types.CurrencyUSD)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.PayMerchant(account.ID, merchant.ID, 100_00)
+	if err != ErrCurrencyMismatch {
+		t.Errorf("PayMerchant(): must return ErrCurrencyMismatch, returned = %v", err)
+		return
+	}
+}
+
+func Test_Repeat_merchant(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	merchant, err := s.RegisterMerchant("Car Shop", "auto", "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	payment, err := s.PayMerchant(account.ID, merchant.ID, 300_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	repeated, err := s.Repeat(payment.ID)
+	if err != nil {
+		t.Errorf("Repeat(): error = %v", err)
+		return
+	}
+	if repeated.MerchantID != merchant.ID || merchant.Balance != 600_00 {
+		t.Errorf("Repeat(): merchant not paid, payment = %v, merchant = %v", repeated, merchant)
+		return
+	}
+
+	_, err = s.RepeatWith(payment.ID, types.RepeatOverrides{Category: "mobile"})
+	if err != ErrMerchantCategory {
+		t.Errorf("RepeatWith(): must return ErrMerchantCategory, returned = %v", err)
+		return
+	}
+	_, err = s.RepeatWith(payment.ID, types.RepeatOverrides{Category: "auto"})
+	if err != nil || merchant.Balance != 900_00 {
+		t.Errorf("RepeatWith(): merchant = %v, error = %v", merchant, err)
+		return
+	}
+
+	favorite, err := s.FavoritePayment(payment.ID, "car")
+	if err != nil {
+		t.Errorf("FavoritePayment(): error = %v", err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+
+	paid, err := imported.PayFromFavorite(favorite.ID)
+	if err != nil {
+		t.Errorf("PayFromFavorite(): error = %v", err)
+		return
+	}
+	got, err := imported.FindMerchantByID(merchant.ID)
+	if err != nil || paid.MerchantID != merchant.ID || got.Balance != 1_200_00 {
+		t.Errorf("PayFromFavorite(): merchant not paid, payment = %v, merchant = %v", paid, got)
+		return
+	}
+}
+
+func Test_Import_merchants(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	merchant, err := s.RegisterMerchant("Pharmacy; 24/7", "pharmacy", "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	payment, err := s.PayMerchant(account.ID, merchant.ID, 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+
+	got, err := imported.FindMerchantByID(merchant.ID)
+	if err != nil || *got != *merchant {
+		t.Errorf("Import(): merchant = %v, error = %v", got, err)
+		return
+	}
+	payments, err := imported.MerchantPayments(merchant.ID)
+	if err != nil || len(payments) != 1 || payments[0].ID != payment.ID {
+		t.Errorf("Import(): merchant payments = %v, error = %v", payments, err)
+		return
+	}
+
+	next, err := imported.RegisterMerchant("Other", "food", "")
+	if err != nil || next.ID == merchant.ID {
+		t.Errorf("RegisterMerchant(): merchant = %v, error = %v", next, err)
+		return
+	}
+}
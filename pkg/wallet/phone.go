@@ -0,0 +1,89 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+type phoneRule struct {
+	code   string
+	digits int
+}
+
+// phoneRules country calling codes with the length of national number, longer
+// codes go first so that the prefix match is unambiguous
+var phoneRules = []phoneRule{
+	{code: "992", digits: 9}, // Tajikistan
+	{code: "998", digits: 9}, // Uzbekistan
+	{code: "996", digits: 9}, // Kyrgyzstan
+	{code: "993", digits: 8}, // Turkmenistan
+	{code: "994", digits: 9}, // Azerbaijan
+	{code: "374", digits: 8}, // Armenia
+	{code: "375", digits: 9}, // Belarus
+	{code: "90", digits: 10}, // Turkey
+	{code: "7", digits: 10},  // Russia, Kazakhstan
+	{code: "1", digits: 10},  // USA, Canada
+}
+
+// NormalizePhone parses phone number with country code, like "+992 00 000 0001"
+// or "00992000000001", and returns it in E.164 format ("+992000000001").
+func NormalizePhone(raw string) (types.Phone, error) {
+	str := strings.TrimSpace(raw)
+	switch {
+	case strings.HasPrefix(str, "+"):
+		str = str[1:]
+	case strings.HasPrefix(str, "00"):
+		str = str[2:]
+	}
+
+	digits := ""
+	for _, char := range str {
+		switch {
+		case char >= '0' && char <= '9':
+			digits += string(char)
+		case strings.ContainsRune(" -.()", char):
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+
+	for _, rule := range phoneRules {
+		if !strings.HasPrefix(digits, rule.code) {
+			continue
+		}
+		if len(digits) != len(rule.code)+rule.digits {
+			return "", ErrInvalidPhone
+		}
+		return types.Phone("+" + digits), nil
+	}
+	return "", ErrInvalidPhone
+}
+
+// FindAccountByPhone finds account by phone in any supported format
+func (s *Service) FindAccountByPhone(phone types.Phone) (*types.Account, error) {
+	normalized, err := NormalizePhone(string(phone))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, account := range s.accounts {
+		if storedPhone(account.Phone) == normalized {
+			return account, nil
+		}
+	}
+	return nil, ErrAccountNotFound
+}
+
+// storedPhone normalizes phone loaded from old dumps, phones that cannot be
+// parsed are kept as is
+func storedPhone(phone types.Phone) types.Phone {
+	normalized, err := NormalizePhone(string(phone))
+	if err != nil {
+		return phone
+	}
+	return normalized
+}
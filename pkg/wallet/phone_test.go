@@ -0,0 +1,119 @@
+package wallet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want types.Phone
+	}{
+		{"992000000001", "+992000000001"},
+		{"+992 00 000 0001", "+992000000001"},
+		{"00992-00-000-00-01", "+992000000001"},
+		{"+7 (912) 345-67-89", "+79123456789"},
+		{"+1 202 555 0100", "+12025550100"},
+	}
+
+	for _, test := range tests {
+		got, err := NormalizePhone(test.raw)
+		if err != nil {
+			t.Errorf("NormalizePhone(%q): error = %v", test.raw, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("NormalizePhone(%q): got = %v, want = %v", test.raw, got, test.want)
+		}
+	}
+}
+
+func TestNormalizePhone_fail(t *testing.T) {
+	for _, raw := range []string{"", "+", "99200000001", "9920000000011", "+992 abc 000 0001", "+380000000000"} {
+		_, err := NormalizePhone(raw)
+		if err != ErrInvalidPhone {
+			t.Errorf("NormalizePhone(%q): must return ErrInvalidPhone, returned = %v", raw, err)
+		}
+	}
+}
+
+func Test_RegisterAccount_normalized(t *testing.T) {
+	s := newTestService()
+	account, err := s.RegisterAccount("992000000001")
+	if err != nil {
+		t.Errorf("RegisterAccount(): error = %v", err)
+		return
+	}
+
+	if account.Phone != "+992000000001" {
+		t.Errorf("RegisterAccount(): phone not normalized, account = %v", account)
+		return
+	}
+
+	_, err = s.RegisterAccount("+992 00 000 0001")
+	if err != ErrPhoneRegistered {
+		t.Errorf("RegisterAccount(): must return ErrPhoneRegistered, returned = %v", err)
+		return
+	}
+
+	_, err = s.RegisterAccount("")
+	if err != ErrInvalidPhone {
+		t.Errorf("RegisterAccount(): must return ErrInvalidPhone, returned = %v", err)
+		return
+	}
+
+	found, err := s.FindAccountByPhone("00 992 000 000 001")
+	if err != nil || found != account {
+		t.Errorf("FindAccountByPhone(): wrong account = %v, error = %v", found, err)
+		return
+	}
+}
+
+func Test_Import_legacyPhone(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "accounts.dump"), []byte("1;992000000001;100"), 0666)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	s := newTestService()
+	err = s.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+
+	account, err := s.FindAccountByPhone("992000000001")
+	if err != nil || account.Phone != "+992000000001" {
+		t.Errorf("FindAccountByPhone(): account = %v, error = %v", account, err)
+		return
+	}
+
+	_, err = s.RegisterAccount("992000000001")
+	if err != ErrPhoneRegistered {
+		t.Errorf("RegisterAccount(): must return ErrPhoneRegistered, returned = %v", err)
+		return
+	}
+
+	path := filepath.Join(dir, "export.txt")
+	err = os.WriteFile(path, []byte("2;992000000002;100"), 0666)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.ImportFromFile(path)
+	if err != nil {
+		t.Errorf("ImportFromFile(): error = %v", err)
+		return
+	}
+	_, err = s.FindAccountByPhone("+992 00 000 0002")
+	if err != nil {
+		t.Errorf("FindAccountByPhone(): error = %v", err)
+		return
+	}
+}
@@ -0,0 +1,191 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrPocketNotFound = errors.New("pocket not found")
+var ErrInvalidPocket = errors.New("invalid pocket")
+var ErrPocketNotEmpty = errors.New("pocket balance is not zero")
+
+// CreatePocket adds savings pocket to the account, zero target or target date
+// means no goal
+func (s *Service) CreatePocket(accountID int64, name string, target types.Money, targetDate time.Time) (*types.Pocket, error) {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkAccountActive(account)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || target < 0 {
+		return nil, ErrInvalidPocket
+	}
+	for _, pocket := range s.pockets {
+		if pocket.AccountID == account.ID && strings.EqualFold(pocket.Name, name) {
+			return nil, ErrInvalidPocket
+		}
+	}
+
+	pocket := &types.Pocket{
+		ID:         uuid.New().String(),
+		AccountID:  account.ID,
+		Name:       name,
+		Target:     target,
+		TargetDate: targetDate,
+	}
+	s.pockets = append(s.pockets, pocket)
+	return pocket, nil
+}
+
+func (s *Service) FindPocketByID(pocketID string) (*types.Pocket, error) {
+	for _, pocket := range s.pockets {
+		if pocket.ID == pocketID {
+			return pocket, nil
+		}
+	}
+	return nil, ErrPocketNotFound
+}
+
+// Pockets returns pockets of the account in creation order
+func (s *Service) Pockets(accountID int64) ([]types.Pocket, error) {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	pockets := []types.Pocket{}
+	for _, pocket := range s.pockets {
+		if pocket.AccountID == account.ID {
+			pockets = append(pockets, *pocket)
+		}
+	}
+	return pockets, nil
+}
+
+// MoveToPocket sets aside amount of the available main balance in the pocket
+func (s *Service) MoveToPocket(pocketID string, amount types.Money) error {
+	pocket, account, err := s.pocketAccount(pocketID, amount)
+	if err != nil {
+		return err
+	}
+
+	s.ExpireHolds()
+	if account.Available() < amount {
+		return ErrNotEnoughBalance
+	}
+
+	account.Pocketed += amount
+	pocket.Balance += amount
+	return nil
+}
+
+// MoveFromPocket returns amount from the pocket to the main balance
+func (s *Service) MoveFromPocket(pocketID string, amount types.Money) error {
+	pocket, account, err := s.pocketAccount(pocketID, amount)
+	if err != nil {
+		return err
+	}
+
+	if pocket.Balance < amount {
+		return ErrNotEnoughBalance
+	}
+
+	account.Pocketed -= amount
+	pocket.Balance -= amount
+	return nil
+}
+
+// SetRoundUp makes payments of the account round up to unit with the spare
+// change moved into the pocket. Only one pocket of an account rounds up, zero
+// unit disables round-ups.
+func (s *Service) SetRoundUp(pocketID string, unit types.Money) error {
+	pocket, err := s.FindPocketByID(pocketID)
+	if err != nil {
+		return err
+	}
+
+	if unit < 0 {
+		return ErrInvalidPocket
+	}
+	for _, other := range s.pockets {
+		if other.AccountID == pocket.AccountID {
+			other.RoundUp = 0
+		}
+	}
+	pocket.RoundUp = unit
+	return nil
+}
+
+// DeletePocket removes an empty pocket
+func (s *Service) DeletePocket(pocketID string) error {
+	pocket, err := s.FindPocketByID(pocketID)
+	if err != nil {
+		return err
+	}
+
+	if pocket.Balance != 0 {
+		return ErrPocketNotEmpty
+	}
+
+	pockets := []*types.Pocket{}
+	for _, other := range s.pockets {
+		if other.ID != pocket.ID {
+			pockets = append(pockets, other)
+		}
+	}
+	s.pockets = pockets
+	return nil
+}
+
+func (s *Service) pocketAccount(pocketID string, amount types.Money) (*types.Pocket, *types.Account, error) {
+	if amount <= 0 {
+		return nil, nil, ErrAmountmustBePositive
+	}
+
+	pocket, err := s.FindPocketByID(pocketID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	account, err := s.FindAccountByID(pocket.AccountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = checkAccountActive(account)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pocket, account, nil
+}
+
+// sweepRoundUp moves spare change of the payment into the round-up pocket of
+// the account, it is skipped when the main balance cannot cover it
+func (s *Service) sweepRoundUp(account *types.Account, payment *types.Payment) {
+	pocket, spare := s.roundUp(account.ID, payment.Amount)
+	if spare > 0 && account.Available() >= spare {
+		account.Pocketed += spare
+		pocket.Balance += spare
+	}
+}
+
+// roundUp returns the round-up pocket of the account and spare change of a
+// payment of amount
+func (s *Service) roundUp(accountID int64, amount types.Money) (*types.Pocket, types.Money) {
+	for _, pocket := range s.pockets {
+		if pocket.AccountID == accountID && pocket.RoundUp > 0 {
+			return pocket, (pocket.RoundUp - amount%pocket.RoundUp) % pocket.RoundUp
+		}
+	}
+	return nil, 0
+}
@@ -0,0 +1,169 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_Pocket_success(t *testing.T) {
+	s := newTestService()
+	accounts, err := s.addBatchAccounts(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	account := accounts[0]
+
+	pocket, err := s.CreatePocket(account.ID, " Vacation ", 500_00, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Errorf("CreatePocket(): error = %v", err)
+		return
+	}
+
+	err = s.MoveToPocket(pocket.ID, 800_00)
+	if err != nil {
+		t.Errorf("MoveToPocket(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 250_00, "food")
+	if err != ErrNotEnoughBalance {
+		t.Errorf("Pay(): must return ErrNotEnoughBalance, returned = %v", err)
+		return
+	}
+
+	err = s.MoveFromPocket(pocket.ID, 100_00)
+	if err != nil {
+		t.Errorf("MoveFromPocket(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 250_00, "food")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	if account.Balance != 750_00 || account.Available() != 50_00 || pocket.Balance != 700_00 || pocket.Remaining() != 0 || pocket.Name != "Vacation" {
+		t.Errorf("Pay(): account = %v, pocket = %v", account, pocket)
+		return
+	}
+}
+
+func Test_Pocket_roundUp(t *testing.T) {
+	s := newTestService()
+	accounts, err := s.addBatchAccounts(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	account := accounts[0]
+
+	pocket, err := s.CreatePocket(account.ID, "spare change", 0, time.Time{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetRoundUp(pocket.ID, 1_00)
+	if err != nil {
+		t.Errorf("SetRoundUp(): error = %v", err)
+		return
+	}
+
+	for _, amount := range []types.Money{3_40, 10_00, 99_99} {
+		_, err = s.Pay(account.ID, amount, "food")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	if pocket.Balance != 61 || account.Pocketed != 61 || account.Available() != 1_000_00-113_39-61 {
+		t.Errorf("Pay(): account = %v, pocket = %v", account, pocket)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	pockets, err := imported.Pockets(account.ID)
+	if err != nil || len(pockets) != 1 || pockets[0] != *pocket {
+		t.Errorf("Import(): pockets = %v, error = %v", pockets, err)
+		return
+	}
+	got, err := imported.FindAccountByID(account.ID)
+	if err != nil || got.Pocketed != 61 {
+		t.Errorf("Import(): account = %v, error = %v", got, err)
+		return
+	}
+}
+
+func Test_Pocket_fail(t *testing.T) {
+	s := newTestService()
+	accounts, err := s.addBatchAccounts(2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	account := accounts[0]
+
+	pocket, err := s.CreatePocket(account.ID, "car", 0, time.Time{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.CreatePocket(account.ID, "CAR", 0, time.Time{})
+	if err != ErrInvalidPocket {
+		t.Errorf("CreatePocket(): must return ErrInvalidPocket, returned = %v", err)
+		return
+	}
+
+	err = s.MoveToPocket(pocket.ID, 1_000_01)
+	if err != ErrNotEnoughBalance {
+		t.Errorf("MoveToPocket(): must return ErrNotEnoughBalance, returned = %v", err)
+		return
+	}
+	err = s.MoveToPocket(pocket.ID, 10_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.DeletePocket(pocket.ID)
+	if err != ErrPocketNotEmpty {
+		t.Errorf("DeletePocket(): must return ErrPocketNotEmpty, returned = %v", err)
+		return
+	}
+	err = s.CloseWithPayout(account.ID, accounts[1].ID)
+	if err != ErrPocketNotEmpty {
+		t.Errorf("CloseWithPayout(): must return ErrPocketNotEmpty, returned = %v", err)
+		return
+	}
+
+	err = s.MoveFromPocket(pocket.ID, 10_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.DeletePocket(pocket.ID)
+	if err != nil {
+		t.Errorf("DeletePocket(): error = %v", err)
+		return
+	}
+	err = s.CloseWithPayout(account.ID, accounts[1].ID)
+	if err != nil {
+		t.Errorf("CloseWithPayout(): error = %v", err)
+		return
+	}
+}
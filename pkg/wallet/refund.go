@@ -0,0 +1,78 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/fm2901/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrRefundExceedsPayment = errors.New("refund exceeds payment amount")
+
+// Refund returns part of the payment amount to the account. A payment may be
+// refunded several times until the refunded total reaches its amount.
+func (s *Service) Refund(paymentID string, amount types.Money) (*types.Refund, error) {
+	if amount <= 0 {
+		return nil, ErrAmountmustBePositive
+	}
+
+	payment, err := s.FindPaymentByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Status == types.PaymentStatusFail {
+		return nil, ErrPaymentRejected
+	}
+
+	if payment.Refunded+amount > payment.Amount {
+		return nil, ErrRefundExceedsPayment
+	}
+
+	account, err := s.FindAccountByID(payment.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	merchant, err := s.paymentMerchant(payment)
+	if err != nil {
+		return nil, err
+	}
+	cashback, points := s.loyaltyReversal(payment, payment.Amount-payment.Refunded-amount)
+
+	if merchant != nil {
+		merchant.Balance -= amount
+	}
+	account.Balance += amount
+	payment.Refunded += amount
+	if payment.Refunded == payment.Amount {
+		payment.Status = types.PaymentStatusRefunded
+	} else {
+		payment.Status = types.PaymentStatusPartRefund
+	}
+	s.reverseLoyalty(account, payment, cashback, points)
+
+	refund := &types.Refund{
+		ID:        uuid.New().String(),
+		PaymentID: payment.ID,
+		Amount:    amount,
+	}
+	s.refunds = append(s.refunds, refund)
+	return refund, nil
+}
+
+// PaymentRefunds returns all refunds made for the payment
+func (s *Service) PaymentRefunds(paymentID string) ([]types.Refund, error) {
+	payment, err := s.FindPaymentByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	refunds := []types.Refund{}
+	for _, refund := range s.refunds {
+		if refund.PaymentID == payment.ID {
+			refunds = append(refunds, *refund)
+		}
+	}
+	return refunds, nil
+}
@@ -0,0 +1,110 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_Refund_success(t *testing.T) {
+	s := newTestService()
+	account, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment := payments[0]
+	_, err = s.Refund(payment.ID, 400_00)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+
+	if payment.Status != types.PaymentStatusPartRefund {
+		t.Errorf("Refund(): wrong status, payment = %v", payment)
+		return
+	}
+
+	_, err = s.Refund(payment.ID, 600_00)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+
+	if payment.Status != types.PaymentStatusRefunded || payment.Refunded != payment.Amount {
+		t.Errorf("Refund(): wrong status, payment = %v", payment)
+		return
+	}
+
+	if account.Balance != defaultTestAccount.balance {
+		t.Errorf("Refund(): balance didn't changed, account = %v", account)
+		return
+	}
+
+	refunds, err := s.PaymentRefunds(payment.ID)
+	if err != nil {
+		t.Errorf("PaymentRefunds(): error = %v", err)
+		return
+	}
+
+	if len(refunds) != 2 {
+		t.Errorf("PaymentRefunds(): wrong refunds returned = %v", refunds)
+		return
+	}
+}
+
+func Test_Refund_fail(t *testing.T) {
+	s := newTestService()
+	_, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment := payments[0]
+	_, err = s.Refund(payment.ID, payment.Amount+1)
+	if err != ErrRefundExceedsPayment {
+		t.Errorf("Refund(): must return ErrRefundExceedsPayment, returned = %v", err)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+
+	_, err = s.Refund(payment.ID, 1)
+	if err != ErrPaymentRejected {
+		t.Errorf("Refund(): must return ErrPaymentRejected, returned = %v", err)
+		return
+	}
+}
+
+func Test_Reject_afterRefund(t *testing.T) {
+	s := newTestService()
+	account, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment := payments[0]
+	_, err = s.Refund(payment.ID, 300_00)
+	if err != nil {
+		t.Errorf("Refund(): error = %v", err)
+		return
+	}
+
+	err = s.Reject(payment.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+
+	if account.Balance != defaultTestAccount.balance {
+		t.Errorf("Reject(): refunded twice, account = %v", account)
+		return
+	}
+}
@@ -0,0 +1,87 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+var ErrMerchantCategory = errors.New("category of merchant payment cannot be changed")
+
+// RepeatResult result of repeating one payment in RepeatAll, Payment is nil
+// when Err is set
+type RepeatResult struct {
+	PaymentID string
+	Payment   *types.Payment
+	Err       error
+}
+
+// RepeatWith repeats the payment applying overrides. Merchant payments are
+// paid to the same merchant in its category, a Category override naming
+// another category returns ErrMerchantCategory.
+func (s *Service) RepeatWith(paymentID string, overrides types.RepeatOverrides) (*types.Payment, error) {
+	payment, err := s.FindPaymentByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := payment.Amount
+	if overrides.Amount != 0 {
+		amount = overrides.Amount
+	}
+	category := payment.Category
+	if overrides.Category != "" {
+		category = overrides.Category
+	}
+
+	var repeated *types.Payment
+	if payment.MerchantID != 0 {
+		var merchant *types.Merchant
+		merchant, err = s.FindMerchantByID(payment.MerchantID)
+		if err != nil {
+			return nil, err
+		}
+		if overrides.Category != "" {
+			category, err = s.ResolveCategory(string(overrides.Category))
+			if err != nil {
+				return nil, err
+			}
+			if category != merchant.Category {
+				return nil, ErrMerchantCategory
+			}
+		}
+		repeated, err = s.PayMerchant(payment.AccountID, merchant.ID, amount)
+	} else {
+		repeated, err = s.Pay(payment.AccountID, amount, category)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payment.Metadata) > 0 {
+		repeated.Metadata = map[string]string{}
+		for key, value := range payment.Metadata {
+			repeated.Metadata[key] = value
+		}
+	}
+	return repeated, nil
+}
+
+// RepeatAll repeats every payment matching filter in creation order, nil
+// filter matches all payments. A failed repeat is reported in its result and
+// does not stop the rest.
+func (s *Service) RepeatAll(filter func(payment types.Payment) bool, overrides types.RepeatOverrides) []RepeatResult {
+	matched := []string{}
+	for _, payment := range s.payments {
+		if filter == nil || filter(*payment) {
+			matched = append(matched, payment.ID)
+		}
+	}
+
+	results := []RepeatResult{}
+	for _, paymentID := range matched {
+		payment, err := s.RepeatWith(paymentID, overrides)
+		results = append(results, RepeatResult{PaymentID: paymentID, Payment: payment, Err: err})
+	}
+	return results
+}
@@ -0,0 +1,85 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_RepeatWith_success(t *testing.T) {
+	s := newTestService()
+	_, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.RepeatWith(payments[0].ID, types.RepeatOverrides{Amount: 15_00, Category: "mobile"})
+	if err != nil {
+		t.Errorf("RepeatWith(): error = %v", err)
+		return
+	}
+
+	if payment.Amount != 15_00 || payment.Category != "mobile" || payment.AccountID != payments[0].AccountID {
+		t.Errorf("RepeatWith(): wrong payment = %v", payment)
+		return
+	}
+}
+
+func Test_RepeatAll_partial(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	small, err := s.Pay(account.ID, 100_00, "utility")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	large, err := s.Pay(account.ID, 900_00, "utility")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetSpendingLimits(account.ID, types.SpendingLimits{PerTransaction: 500_00})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	results := s.RepeatAll(func(payment types.Payment) bool {
+		return payment.Category == "utility"
+	}, types.RepeatOverrides{})
+
+	if len(results) != 2 || results[0].PaymentID != small.ID || results[1].PaymentID != large.ID {
+		t.Errorf("RepeatAll(): wrong results = %v", results)
+		return
+	}
+	if results[0].Err != nil || results[0].Payment.Amount != 100_00 {
+		t.Errorf("RepeatAll(): first repeat result = %v", results[0])
+		return
+	}
+	if results[1].Err == nil || results[1].Payment != nil {
+		t.Errorf("RepeatAll(): second repeat must fail, result = %v", results[1])
+		return
+	}
+}
+
+func Test_RepeatAll_nilFilter(t *testing.T) {
+	s := newTestService()
+	_, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	results := s.RepeatAll(nil, types.RepeatOverrides{})
+	if len(results) != len(payments) || results[0].PaymentID != payments[0].ID || results[0].Err != nil {
+		t.Errorf("RepeatAll(): wrong results = %v", results)
+		return
+	}
+}
@@ -0,0 +1,166 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+// DefaultRequestTTL lifetime of a money request when RequestMoney is called
+// without ttl
+const DefaultRequestTTL = 72 * time.Hour
+
+var ErrRequestNotFound = errors.New("money request not found")
+var ErrRequestNotPending = errors.New("money request is not pending")
+
+// RequestMoney asks the owner of phone to pay amount to the account, the
+// request expires after ttl
+func (s *Service) RequestMoney(accountID int64, phone types.Phone, amount types.Money, note string, ttl time.Duration) (*types.MoneyRequest, error) {
+	if amount <= 0 {
+		return nil, ErrAmountmustBePositive
+	}
+
+	from, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.FindAccountByPhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	if from.ID == to.ID {
+		return nil, ErrSameAccount
+	}
+	err = checkAccountActive(from)
+	if err != nil {
+		return nil, err
+	}
+	if from.Currency != to.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultRequestTTL
+	}
+
+	now := s.now()
+	request := &types.MoneyRequest{
+		ID:            uuid.New().String(),
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		Note:          strings.TrimSpace(note),
+		Status:        types.RequestStatusPending,
+		Created:       now,
+		Expires:       now.Add(ttl),
+	}
+	s.requests = append(s.requests, request)
+	return request, nil
+}
+
+// AcceptRequest pays the request from the requested account with a transfer
+func (s *Service) AcceptRequest(requestID string, accountID int64) (*types.Transfer, error) {
+	request, err := s.pendingRequest(requestID, accountID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err := s.Transfer(request.ToAccountID, request.FromAccountID, request.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Status = types.RequestStatusAccepted
+	request.TransferID = transfer.ID
+	return transfer, nil
+}
+
+// DeclineRequest declines the request by the requested account
+func (s *Service) DeclineRequest(requestID string, accountID int64) error {
+	request, err := s.pendingRequest(requestID, accountID, false)
+	if err != nil {
+		return err
+	}
+
+	request.Status = types.RequestStatusDeclined
+	return nil
+}
+
+// CancelRequest cancels the request by the requesting account
+func (s *Service) CancelRequest(requestID string, accountID int64) error {
+	request, err := s.pendingRequest(requestID, accountID, true)
+	if err != nil {
+		return err
+	}
+
+	request.Status = types.RequestStatusCancelled
+	return nil
+}
+
+// ExpireRequests marks pending requests whose lifetime is over as expired and
+// returns their count
+func (s *Service) ExpireRequests() int {
+	now := s.now()
+	count := 0
+	for _, request := range s.requests {
+		if request.Status != types.RequestStatusPending || now.Before(request.Expires) {
+			continue
+		}
+		request.Status = types.RequestStatusExpired
+		count++
+	}
+	return count
+}
+
+// MoneyRequests returns requests sent and received by the account
+func (s *Service) MoneyRequests(accountID int64) ([]types.MoneyRequest, error) {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ExpireRequests()
+	requests := []types.MoneyRequest{}
+	for _, request := range s.requests {
+		if request.FromAccountID == account.ID || request.ToAccountID == account.ID {
+			requests = append(requests, *request)
+		}
+	}
+	return requests, nil
+}
+
+func (s *Service) FindRequestByID(requestID string) (*types.MoneyRequest, error) {
+	for _, request := range s.requests {
+		if request.ID == requestID {
+			return request, nil
+		}
+	}
+	return nil, ErrRequestNotFound
+}
+
+// pendingRequest finds pending request of the account, requester selects
+// whether the account must be the requesting or the requested side
+func (s *Service) pendingRequest(requestID string, accountID int64, requester bool) (*types.MoneyRequest, error) {
+	request, err := s.FindRequestByID(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := request.ToAccountID
+	if requester {
+		owner = request.FromAccountID
+	}
+	if owner != accountID {
+		return nil, ErrRequestNotFound
+	}
+
+	s.ExpireRequests()
+	if request.Status != types.RequestStatusPending {
+		return nil, ErrRequestNotPending
+	}
+	return request, nil
+}
@@ -0,0 +1,143 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_AcceptRequest_success(t *testing.T) {
+	s := newTestService()
+	payer, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	requester, err := s.RegisterAccount("992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	request, err := s.RequestMoney(requester.ID, "+992 00 000 0001", 150_00, "dinner", 0)
+	if err != nil {
+		t.Errorf("RequestMoney(): error = %v", err)
+		return
+	}
+	if request.ToAccountID != payer.ID || request.Status != types.RequestStatusPending {
+		t.Errorf("RequestMoney(): wrong request = %v", request)
+		return
+	}
+
+	_, err = s.AcceptRequest(request.ID, requester.ID)
+	if err != ErrRequestNotFound {
+		t.Errorf("AcceptRequest(): must return ErrRequestNotFound, returned = %v", err)
+		return
+	}
+
+	balance := payer.Balance
+	transfer, err := s.AcceptRequest(request.ID, payer.ID)
+	if err != nil {
+		t.Errorf("AcceptRequest(): error = %v", err)
+		return
+	}
+	if requester.Balance != 150_00 || payer.Balance != balance-150_00 || request.Status != types.RequestStatusAccepted || request.TransferID != transfer.ID {
+		t.Errorf("AcceptRequest(): request = %v, requester = %v", request, requester)
+		return
+	}
+
+	err = s.DeclineRequest(request.ID, payer.ID)
+	if err != ErrRequestNotPending {
+		t.Errorf("DeclineRequest(): must return ErrRequestNotPending, returned = %v", err)
+		return
+	}
+}
+
+func Test_MoneyRequests_statuses(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)}
+	s.SetClock(clock)
+	payer, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	requester, err := s.RegisterAccount("992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.RequestMoney(requester.ID, "992000000002", 10_00, "", 0)
+	if err != ErrSameAccount {
+		t.Errorf("RequestMoney(): must return ErrSameAccount, returned = %v", err)
+		return
+	}
+
+	declined, err := s.RequestMoney(requester.ID, "992000000001", 10_00, "", 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	cancelled, err := s.RequestMoney(requester.ID, "992000000001", 20_00, "", 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	expired, err := s.RequestMoney(requester.ID, "992000000001", 30_00, "", time.Hour)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.DeclineRequest(declined.ID, payer.ID)
+	if err != nil {
+		t.Errorf("DeclineRequest(): error = %v", err)
+		return
+	}
+	err = s.CancelRequest(cancelled.ID, requester.ID)
+	if err != nil {
+		t.Errorf("CancelRequest(): error = %v", err)
+		return
+	}
+
+	clock.advance(time.Hour)
+	_, err = s.AcceptRequest(expired.ID, payer.ID)
+	if err != ErrRequestNotPending {
+		t.Errorf("AcceptRequest(): must return ErrRequestNotPending, returned = %v", err)
+		return
+	}
+
+	requests, err := s.MoneyRequests(payer.ID)
+	if err != nil || len(requests) != 3 {
+		t.Errorf("MoneyRequests(): requests = %v, error = %v", requests, err)
+		return
+	}
+	want := []types.RequestStatus{types.RequestStatusDeclined, types.RequestStatusCancelled, types.RequestStatusExpired}
+	for i, request := range requests {
+		if request.Status != want[i] {
+			t.Errorf("MoneyRequests(): request = %v, want status = %v", request, want[i])
+			return
+		}
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	imported := newTestService()
+	imported.SetClock(clock)
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	got, err := imported.MoneyRequests(requester.ID)
+	if err != nil || len(got) != 3 || got[2].Status != types.RequestStatusExpired || !got[2].Expires.Equal(expired.Expires) {
+		t.Errorf("Import(): requests = %v, error = %v", got, err)
+		return
+	}
+}
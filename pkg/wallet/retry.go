@@ -0,0 +1,95 @@
+package wallet
+
+import (
+	"errors"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrNoRetry = errors.New("order has no pending retry")
+
+// SetRetryPolicy sets how standing orders failed for lack of balance are
+// retried, zero policy disables retries
+func (s *Service) SetRetryPolicy(policy types.RetryPolicy) {
+	s.retryPolicy = policy
+}
+
+// QueuePayment queues a one time payment executed by the next RunDue and
+// retried according to the retry policy
+func (s *Service) QueuePayment(accountID int64, amount types.Money, category types.PaymentCategory) (*types.StandingOrder, error) {
+	if amount <= 0 {
+		return nil, ErrAmountmustBePositive
+	}
+
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	order := &types.StandingOrder{
+		ID:         uuid.New().String(),
+		AccountID:  account.ID,
+		Amount:     amount,
+		Category:   category,
+		Recurrence: types.RecurrenceOnce,
+		NextRun:    s.now(),
+		Active:     true,
+	}
+	s.orders = append(s.orders, order)
+	return order, nil
+}
+
+// CancelRetry drops the pending retry of the order, a one time order is
+// cancelled completely
+func (s *Service) CancelRetry(orderID string) error {
+	order, err := s.FindOrderByID(orderID)
+	if err != nil {
+		return err
+	}
+
+	if order.RetryAt.IsZero() {
+		return ErrNoRetry
+	}
+
+	order.Attempts = 0
+	order.RetryAt = time.Time{}
+	if order.Recurrence == types.RecurrenceOnce {
+		order.Active = false
+	}
+	return nil
+}
+
+func (s *Service) scheduleRetry(order *types.StandingOrder, err error, now time.Time) {
+	policy := s.retryPolicy
+	order.Attempts++
+	if !errors.Is(err, ErrNotEnoughBalance) || order.Attempts >= policy.MaxAttempts {
+		order.Attempts = 0
+		order.RetryAt = time.Time{}
+		return
+	}
+
+	backoff := policy.InitialBackoff
+	for i := 1; i < order.Attempts && (policy.MaxBackoff <= 0 || backoff < policy.MaxBackoff); i++ {
+		backoff *= 2
+	}
+	if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+		backoff = policy.MaxBackoff
+	}
+	order.RetryAt = now.Add(backoff)
+}
+
+// retryAfterDeposit makes pending retries of the account due right away
+func (s *Service) retryAfterDeposit(accountID int64) {
+	if !s.retryPolicy.RetryAfterDeposit {
+		return
+	}
+
+	now := s.now()
+	for _, order := range s.orders {
+		if order.AccountID == accountID && order.Active && order.RetryAt.After(now) {
+			order.RetryAt = now
+		}
+	}
+}
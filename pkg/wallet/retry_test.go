@@ -0,0 +1,123 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_RunDue_retry(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC)}
+	s.SetClock(clock)
+	s.SetRetryPolicy(types.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour, MaxBackoff: 90 * time.Minute})
+	account, err := s.RegisterAccount("992000000001")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	order, err := s.QueuePayment(account.ID, 100_00, "mobile")
+	if err != nil {
+		t.Errorf("QueuePayment(): error = %v", err)
+		return
+	}
+
+	runs := s.RunDue()
+	if len(runs) != 1 || runs[0].Status != types.RunStatusFail {
+		t.Errorf("RunDue(): wrong runs = %v", runs)
+		return
+	}
+	if order.Attempts != 1 || !order.RetryAt.Equal(clock.now.Add(time.Hour)) || !order.Active {
+		t.Errorf("RunDue(): retry not scheduled, order = %v", order)
+		return
+	}
+
+	clock.advance(time.Hour)
+	s.RunDue()
+	if order.Attempts != 2 || !order.RetryAt.Equal(clock.now.Add(90*time.Minute)) {
+		t.Errorf("RunDue(): wrong backoff, order = %v", order)
+		return
+	}
+
+	clock.advance(90 * time.Minute)
+	runs = s.RunDue()
+	if len(runs) != 1 || runs[0].Attempt != 3 || order.Active || !order.RetryAt.IsZero() {
+		t.Errorf("RunDue(): order must give up, order = %v, runs = %v", order, runs)
+		return
+	}
+}
+
+func Test_RunDue_retryAfterDeposit(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC)}
+	s.SetClock(clock)
+	s.SetRetryPolicy(types.RetryPolicy{MaxAttempts: 5, InitialBackoff: 24 * time.Hour, RetryAfterDeposit: true})
+	account, err := s.RegisterAccount("992000000001")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	order, err := s.QueuePayment(account.ID, 100_00, "mobile")
+	if err != nil {
+		t.Errorf("QueuePayment(): error = %v", err)
+		return
+	}
+
+	s.RunDue()
+	err = s.Deposit(account.ID, 100_00)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+
+	runs := s.RunDue()
+	if len(runs) != 1 || runs[0].Status != types.RunStatusOK {
+		t.Errorf("RunDue(): retry not triggered by deposit, runs = %v", runs)
+		return
+	}
+
+	if order.Active || order.Attempts != 0 || account.Balance != 0 {
+		t.Errorf("RunDue(): wrong state, order = %v, account = %v", order, account)
+		return
+	}
+}
+
+func Test_CancelRetry(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC)}
+	s.SetClock(clock)
+	s.SetRetryPolicy(types.RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour})
+	account, err := s.RegisterAccount("992000000001")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	order, err := s.QueuePayment(account.ID, 100_00, "mobile")
+	if err != nil {
+		t.Errorf("QueuePayment(): error = %v", err)
+		return
+	}
+
+	err = s.CancelRetry(order.ID)
+	if err != ErrNoRetry {
+		t.Errorf("CancelRetry(): must return ErrNoRetry, returned = %v", err)
+		return
+	}
+
+	s.RunDue()
+	err = s.CancelRetry(order.ID)
+	if err != nil {
+		t.Errorf("CancelRetry(): error = %v", err)
+		return
+	}
+
+	clock.advance(time.Hour)
+	if runs := s.RunDue(); len(runs) != 0 || order.Active {
+		t.Errorf("RunDue(): cancelled retry executed, runs = %v", runs)
+		return
+	}
+}
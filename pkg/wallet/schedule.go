@@ -0,0 +1,199 @@
+package wallet
+
+import (
+	"errors"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrOrderNotFound = errors.New("standing order not found")
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// ScheduleFavorite creates a standing order which pays the favorite at the
+// given time and then according to the recurrence. For monthly orders day is
+// the day of month (clamped to the month length), zero means the day of at.
+func (s *Service) ScheduleFavorite(favoriteID string, at time.Time, recurrence types.Recurrence, day int) (*types.StandingOrder, error) {
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch recurrence {
+	case types.RecurrenceOnce, types.RecurrenceDaily, types.RecurrenceWeekly:
+		day = 0
+	case types.RecurrenceMonthly:
+		if day == 0 {
+			day = at.Day()
+		}
+		if day < 1 || day > 31 {
+			return nil, ErrInvalidSchedule
+		}
+		at = monthDay(at.Year(), at.Month(), day, at)
+		if at.Before(s.now()) {
+			at = monthDay(at.Year(), at.Month()+1, day, at)
+		}
+	default:
+		return nil, ErrInvalidSchedule
+	}
+
+	order := &types.StandingOrder{
+		ID:         uuid.New().String(),
+		FavoriteID: favorite.ID,
+		AccountID:  favorite.AccountID,
+		Recurrence: recurrence,
+		Day:        day,
+		NextRun:    at,
+		Active:     true,
+	}
+	s.orders = append(s.orders, order)
+	return order, nil
+}
+
+func (s *Service) FindOrderByID(orderID string) (*types.StandingOrder, error) {
+	for _, order := range s.orders {
+		if order.ID == orderID {
+			return order, nil
+		}
+	}
+	return nil, ErrOrderNotFound
+}
+
+// CancelOrder stops the standing order
+func (s *Service) CancelOrder(orderID string) error {
+	order, err := s.FindOrderByID(orderID)
+	if err != nil {
+		return err
+	}
+	order.Active = false
+	return nil
+}
+
+// StandingOrders returns standing orders of the account
+func (s *Service) StandingOrders(accountID int64) ([]types.StandingOrder, error) {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := []types.StandingOrder{}
+	for _, order := range s.orders {
+		if order.AccountID == account.ID {
+			orders = append(orders, *order)
+		}
+	}
+	return orders, nil
+}
+
+// OrderRuns returns history of the standing order executions
+func (s *Service) OrderRuns(orderID string) ([]types.OrderRun, error) {
+	order, err := s.FindOrderByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := []types.OrderRun{}
+	for _, run := range s.orderRuns {
+		if run.OrderID == order.ID {
+			runs = append(runs, *run)
+		}
+	}
+	return runs, nil
+}
+
+// RunDue executes every active standing order whose time has come, including
+// pending retries, and returns the outcomes. Missed periods are not caught up,
+// an order runs once per call.
+func (s *Service) RunDue() []types.OrderRun {
+	now := s.now()
+	runs := []types.OrderRun{}
+	for _, order := range s.orders {
+		if !order.Active {
+			continue
+		}
+
+		retrying := !order.RetryAt.IsZero()
+		regular := !order.NextRun.After(now) && !(retrying && order.Recurrence == types.RecurrenceOnce)
+		retry := retrying && !order.RetryAt.After(now)
+		if !regular && !retry {
+			continue
+		}
+		if regular {
+			order.Attempts = 0
+			order.RetryAt = time.Time{}
+		}
+
+		run := &types.OrderRun{
+			OrderID: order.ID,
+			At:      now,
+			Attempt: order.Attempts + 1,
+			Status:  types.RunStatusOK,
+		}
+		payment, err := s.runOrder(order)
+		if err != nil {
+			run.Status = types.RunStatusFail
+			run.Error = err.Error()
+		} else {
+			run.PaymentID = payment.ID
+		}
+		s.orderRuns = append(s.orderRuns, run)
+		runs = append(runs, *run)
+
+		if regular {
+			s.advanceOrder(order, now)
+		}
+		s.scheduleRetry(order, err, now)
+		if order.Recurrence == types.RecurrenceOnce && order.RetryAt.IsZero() {
+			order.Active = false
+		}
+	}
+	return runs
+}
+
+func (s *Service) runOrder(order *types.StandingOrder) (*types.Payment, error) {
+	if order.FavoriteID != "" {
+		return s.PayFromFavorite(order.FavoriteID)
+	}
+	return s.Pay(order.AccountID, order.Amount, order.Category)
+}
+
+func (s *Service) advanceOrder(order *types.StandingOrder, now time.Time) {
+	if order.Recurrence == types.RecurrenceOnce {
+		return
+	}
+
+	for !order.NextRun.After(now) {
+		switch order.Recurrence {
+		case types.RecurrenceDaily:
+			order.NextRun = order.NextRun.AddDate(0, 0, 1)
+		case types.RecurrenceWeekly:
+			order.NextRun = order.NextRun.AddDate(0, 0, 7)
+		case types.RecurrenceMonthly:
+			next := order.NextRun
+			order.NextRun = monthDay(next.Year(), next.Month()+1, order.Day, next)
+		default:
+			// unknown recurrence would never advance
+			order.Active = false
+			return
+		}
+	}
+}
+
+func validRecurrence(recurrence types.Recurrence) bool {
+	switch recurrence {
+	case types.RecurrenceOnce, types.RecurrenceDaily, types.RecurrenceWeekly, types.RecurrenceMonthly:
+		return true
+	}
+	return false
+}
+
+// monthDay returns the day of the month with the time of day from clock,
+// days past the end of the month are moved to its last day
+func monthDay(year int, month time.Month, day int, clock time.Time) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, clock.Location()).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(year, month, day, clock.Hour(), clock.Minute(), clock.Second(), 0, clock.Location())
+}
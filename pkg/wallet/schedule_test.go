@@ -0,0 +1,245 @@
+package wallet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func (s *testService) addFavorite(clock *testClock) (*types.Account, *types.Favorite, error) {
+	s.SetClock(clock)
+	account, payments, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	favorite, err := s.FavoritePayment(payments[0].ID, "new")
+	if err != nil {
+		return nil, nil, err
+	}
+	return account, favorite, nil
+}
+
+func Test_RunDue_daily(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC)}
+	_, favorite, err := s.addFavorite(clock)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	order, err := s.ScheduleFavorite(favorite.ID, clock.now.Add(time.Hour), types.RecurrenceDaily, 0)
+	if err != nil {
+		t.Errorf("ScheduleFavorite(): error = %v", err)
+		return
+	}
+
+	if runs := s.RunDue(); len(runs) != 0 {
+		t.Errorf("RunDue(): order is not due yet, runs = %v", runs)
+		return
+	}
+
+	clock.advance(time.Hour)
+	runs := s.RunDue()
+	if len(runs) != 1 || runs[0].Status != types.RunStatusOK || runs[0].PaymentID == "" {
+		t.Errorf("RunDue(): wrong runs = %v", runs)
+		return
+	}
+
+	if !order.NextRun.Equal(time.Date(2021, 3, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("RunDue(): wrong next run, order = %v", order)
+		return
+	}
+
+	err = s.CancelOrder(order.ID)
+	if err != nil {
+		t.Errorf("CancelOrder(): error = %v", err)
+		return
+	}
+
+	clock.advance(24 * time.Hour)
+	if runs := s.RunDue(); len(runs) != 0 {
+		t.Errorf("RunDue(): cancelled order executed, runs = %v", runs)
+		return
+	}
+}
+
+func Test_RunDue_monthly(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2021, 1, 31, 9, 0, 0, 0, time.UTC)}
+	_, favorite, err := s.addFavorite(clock)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	order, err := s.ScheduleFavorite(favorite.ID, clock.now, types.RecurrenceMonthly, 31)
+	if err != nil {
+		t.Errorf("ScheduleFavorite(): error = %v", err)
+		return
+	}
+
+	s.RunDue()
+	if !order.NextRun.Equal(time.Date(2021, 2, 28, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("RunDue(): wrong next run, order = %v", order)
+		return
+	}
+
+	clock.now = order.NextRun
+	s.RunDue()
+	if !order.NextRun.Equal(time.Date(2021, 3, 31, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("RunDue(): wrong next run, order = %v", order)
+		return
+	}
+}
+
+func Test_RunDue_fail(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC)}
+	account, favorite, err := s.addFavorite(clock)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	order, err := s.ScheduleFavorite(favorite.ID, clock.now, types.RecurrenceOnce, 0)
+	if err != nil {
+		t.Errorf("ScheduleFavorite(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, account.Balance, "auto")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	runs := s.RunDue()
+	if len(runs) != 1 || runs[0].Status != types.RunStatusFail || runs[0].Error != ErrNotEnoughBalance.Error() {
+		t.Errorf("RunDue(): wrong runs = %v", runs)
+		return
+	}
+
+	if order.Active {
+		t.Errorf("RunDue(): one time order still active, order = %v", order)
+		return
+	}
+
+	history, err := s.OrderRuns(order.ID)
+	if err != nil || len(history) != 1 {
+		t.Errorf("OrderRuns(): got = %v, error = %v", history, err)
+		return
+	}
+
+	_, err = s.ScheduleFavorite(favorite.ID, clock.now, "HOURLY", 0)
+	if err != ErrInvalidSchedule {
+		t.Errorf("ScheduleFavorite(): must return ErrInvalidSchedule, returned = %v", err)
+		return
+	}
+}
+
+func Test_Import_orders(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC)}
+	_, favorite, err := s.addFavorite(clock)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	order, err := s.ScheduleFavorite(favorite.ID, clock.now, types.RecurrenceDaily, 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if runs := s.RunDue(); len(runs) != 1 {
+		t.Errorf("RunDue(): wrong runs = %v", runs)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+
+	clock.advance(24 * time.Hour)
+	if runs := s.RunDue(); len(runs) != 1 {
+		t.Errorf("RunDue(): wrong runs = %v", runs)
+		return
+	}
+	err = s.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	runs, err := s.OrderRuns(order.ID)
+	if err != nil || len(runs) != 2 {
+		t.Errorf("Import(): runs duplicated = %v, error = %v", runs, err)
+		return
+	}
+
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	runs, err = imported.OrderRuns(order.ID)
+	if err != nil || len(runs) != 1 {
+		t.Errorf("Import(): runs = %v, error = %v", runs, err)
+		return
+	}
+}
+
+func Test_Import_invalidRecurrence(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "orders.dump"), []byte("order;favorite;1;YEARLY;0;1614589200;true"), 0666)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	s := newTestService()
+	err = s.Import(dir)
+	if err != ErrInvalidSchedule {
+		t.Errorf("Import(): must return ErrInvalidSchedule, returned = %v", err)
+		return
+	}
+}
+
+func Test_RunDue_unknownRecurrence(t *testing.T) {
+	s := newTestService()
+	clock := &testClock{now: time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC)}
+	_, favorite, err := s.addFavorite(clock)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	order, err := s.ScheduleFavorite(favorite.ID, clock.now, types.RecurrenceDaily, 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	order.Recurrence = ""
+
+	if runs := s.RunDue(); len(runs) != 1 {
+		t.Errorf("RunDue(): wrong runs = %v", runs)
+		return
+	}
+	if order.Active {
+		t.Errorf("RunDue(): order with unknown recurrence left active = %v", order)
+		return
+	}
+}
@@ -10,7 +10,10 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/fm2901/wallet/pkg/exchange"
+	"github.com/fm2901/wallet/pkg/money"
 	"github.com/fm2901/wallet/pkg/types"
 	"github.com/google/uuid"
 )
@@ -21,26 +24,79 @@ var ErrAccountNotFound = errors.New("account not found")
 var ErrNotEnoughBalance = errors.New("not enough balance")
 var ErrPaymentNotFound = errors.New("payment not found by id")
 var ErrFavoriteNotFound = errors.New("favorite not found")
+var ErrPaymentRejected = errors.New("payment already rejected")
+var ErrUnknownCurrency = errors.New("unknown currency")
+var ErrCurrencyMismatch = errors.New("currency does not match account currency")
 
 type Service struct {
 	nextAccountID int64
 	accounts      []*types.Account
 	payments      []*types.Payment
 	favorites     []*types.Favorite
+	refunds       []*types.Refund
+	holds         []*types.Hold
+	transfers     []*types.Transfer
+	limits        map[int64]types.SpendingLimits
+	tierLimits    map[types.Tier]types.TierLimits
+	tierChanges   []*types.TierChange
+	clock         Clock
+
+	idempotency       []*types.IdempotencyRecord
+	idempotencyWindow time.Duration
+
+	rateProvider     exchange.Provider
+	exchangeSpread   int64
+	exchangeRounding exchange.Rounding
+
+	feeRules         []types.FeeRule
+	revenueAccountID int64
+
+	cashbackRules []types.CashbackRule
+	loyalty       []*types.LoyaltyEntry
+
+	orders      []*types.StandingOrder
+	orderRuns   []*types.OrderRun
+	retryPolicy types.RetryPolicy
+
+	nextMerchantID int64
+	merchants      []*types.Merchant
+	payouts        []*types.PayoutBatch
+
+	categories []types.CategoryInfo
+	splits     []*types.Split
+
+	requests []*types.MoneyRequest
+	pockets  []*types.Pocket
 }
 
 func (s *Service) RegisterAccount(phone types.Phone) (*types.Account, error) {
+	return s.RegisterAccountWithCurrency(phone, types.DefaultCurrency)
+}
+
+func (s *Service) RegisterAccountWithCurrency(phone types.Phone, currency types.Currency) (*types.Account, error) {
+	if !currency.Valid() {
+		return nil, ErrUnknownCurrency
+	}
+
+	phone, err := NormalizePhone(string(phone))
+	if err != nil {
+		return nil, err
+	}
+
 	for _, account := range s.accounts {
-		if account.Phone == phone {
+		if storedPhone(account.Phone) == phone {
 			return nil, ErrPhoneRegistered
 		}
 	}
 
 	s.nextAccountID++
 	account := &types.Account{
-		ID:      s.nextAccountID,
-		Phone:   phone,
-		Balance: 0,
+		ID:       s.nextAccountID,
+		Phone:    phone,
+		Balance:  0,
+		Currency: currency,
+		Status:   types.AccountStatusActive,
+		Tier:     types.TierAnonymous,
 	}
 	s.accounts = append(s.accounts, account)
 
@@ -64,10 +120,38 @@ func (s *Service) Deposit(accountID int64, amount types.Money) error {
 		return ErrAccountNotFound
 	}
 
-	account.Balance += amount
+	err := checkAccountActive(account)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkTierBalance(account, amount)
+	if err != nil {
+		return err
+	}
+
+	balance, err := money.Add(account.Balance, amount)
+	if err != nil {
+		return err
+	}
+	account.Balance = balance
+	s.retryAfterDeposit(account.ID)
 	return nil
 }
 
+// DepositAmount deposits money only if it is in the account currency
+func (s *Service) DepositAmount(accountID int64, amount types.Amount) error {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	if account.Currency != amount.Currency {
+		return ErrCurrencyMismatch
+	}
+	return s.Deposit(accountID, amount.Value)
+}
+
 func (s *Service) Pay(accountID int64, amount types.Money, category types.PaymentCategory) (*types.Payment, error) {
 	if amount <= 0 {
 		return nil, ErrAmountmustBePositive
@@ -85,23 +169,90 @@ func (s *Service) Pay(accountID int64, amount types.Money, category types.Paymen
 		return nil, ErrAccountNotFound
 	}
 
-	if account.Balance < amount {
+	err := checkAccountActive(account)
+	if err != nil {
+		return nil, err
+	}
+
+	category, err = s.ResolveCategory(string(category))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkSpendingLimits(account, amount, category, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkTierTurnover(account, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	fee, err := s.fee(account, amount, category)
+	if err != nil {
+		return nil, err
+	}
+
+	var revenue *types.Account
+	if fee > 0 {
+		revenue, err = s.revenueAccount()
+		if err != nil {
+			return nil, err
+		}
+		if revenue.Currency != account.Currency {
+			return nil, ErrCurrencyMismatch
+		}
+	}
+
+	s.ExpireHolds()
+	if account.Available() < amount+fee {
 		return nil, ErrNotEnoughBalance
 	}
 
-	account.Balance -= amount
+	cashback, points, err := s.loyaltyAccrual(account, amount, category, account.Balance-amount-fee)
+	if err != nil {
+		return nil, err
+	}
+
+	account.Balance -= amount + fee
+	if revenue != nil {
+		revenue.Balance += fee
+	}
 	paymentID := uuid.New().String()
 	payment := &types.Payment{
 		ID:        paymentID,
 		AccountID: accountID,
 		Amount:    amount,
+		Currency:  account.Currency,
 		Category:  category,
 		Status:    types.PaymentStatusInProgress,
+		Fee:       fee,
+		Created:   s.now(),
+	}
+	if revenue != nil {
+		payment.FeeAccountID = revenue.ID
 	}
 	s.payments = append(s.payments, payment)
+
+	s.accrueLoyalty(account, payment, cashback, points)
+	s.sweepRoundUp(account, payment)
 	return payment, nil
 }
 
+// PayAmount pays money only if it is in the account currency
+func (s *Service) PayAmount(accountID int64, amount types.Amount, category types.PaymentCategory) (*types.Payment, error) {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account.Currency != amount.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+	return s.Pay(accountID, amount.Value, category)
+}
+
 func (s *Service) FindAccountByID(accountID int64) (*types.Account, error) {
 	for _, acc := range s.accounts {
 		if acc.ID == accountID {
@@ -121,34 +272,54 @@ func (s *Service) FindPaymentByID(paymentID string) (*types.Payment, error) {
 }
 
 func (s *Service) Reject(paymentID string) error {
+	split, err := s.FindSplitByID(paymentID)
+	if err == nil {
+		return s.rejectSplit(split)
+	}
+
 	payment, err := s.FindPaymentByID(paymentID)
 	if err != nil {
 		return err
 	}
 
+	if payment.Status == types.PaymentStatusFail {
+		return ErrPaymentRejected
+	}
+
 	account, err := s.FindAccountByID(payment.AccountID)
 	if err != nil {
 		return err
 	}
 
-	account.Balance += payment.Amount
-	payment.Status = types.PaymentStatusFail
-
-	return nil
-}
+	var revenue *types.Account
+	if payment.Fee > 0 {
+		revenue, err = s.feeAccount(payment)
+		if err != nil {
+			return err
+		}
+	}
 
-func (s *Service) Repeat(paymentID string) (*types.Payment, error) {
-	payment, err := s.FindPaymentByID(paymentID)
+	merchant, err := s.paymentMerchant(payment)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	cashback, points := s.loyaltyReversal(payment, 0)
 
-	payment, err = s.Pay(payment.AccountID, payment.Amount, payment.Category)
-	if err != nil {
-		return nil, err
+	// nothing is changed until every account involved is found
+	if revenue != nil {
+		revenue.Balance -= payment.Fee
+	}
+	if merchant != nil {
+		merchant.Balance -= payment.Amount - payment.Refunded
 	}
+	account.Balance += payment.Amount - payment.Refunded + payment.Fee
+	payment.Status = types.PaymentStatusFail
+	s.reverseLoyalty(account, payment, cashback, points)
+	return nil
+}
 
-	return payment, nil
+func (s *Service) Repeat(paymentID string) (*types.Payment, error) {
+	return s.RepeatWith(paymentID, types.RepeatOverrides{})
 }
 
 func (s *Service) FavoritePayment(paymentID string, name string) (*types.Favorite, error) {
@@ -157,12 +328,20 @@ func (s *Service) FavoritePayment(paymentID string, name string) (*types.Favorit
 		return nil, err
 	}
 
+	name, err = s.checkFavoriteName(payment.AccountID, "", name)
+	if err != nil {
+		return nil, err
+	}
+
 	favorite := &types.Favorite{
 		ID:        uuid.New().String(),
 		AccountID: payment.AccountID,
 		Amount:    payment.Amount,
 		Category:  payment.Category,
 		Name:      name,
+		Position:  len(s.accountFavorites(payment.AccountID)),
+
+		MerchantID: payment.MerchantID,
 	}
 	s.favorites = append(s.favorites, favorite)
 	return favorite, nil
@@ -178,17 +357,7 @@ func (s *Service) FindFavoriteByID(favoriteID string) (*types.Favorite, error) {
 }
 
 func (s *Service) PayFromFavorite(favoriteID string) (*types.Payment, error) {
-	favorite, err := s.FindFavoriteByID(favoriteID)
-	if err != nil {
-		return nil, err
-	}
-
-	payment, err := s.Pay(favorite.AccountID, favorite.Amount, favorite.Category)
-	if err != nil {
-		return nil, err
-	}
-
-	return payment, nil
+	return s.PayFromTemplate(favoriteID, 0, nil)
 }
 
 func (s *Service) ExportToFile(path string) error {
@@ -208,7 +377,7 @@ func (s *Service) ExportToFile(path string) error {
 		id := strconv.Itoa(int(acc.ID))
 		phone := string(acc.Phone)
 		balance := strconv.Itoa(int(acc.Balance))
-		accountStr += id + ";" + phone + ";" + balance + "|"
+		accountStr += id + ";" + phone + ";" + balance + ";" + string(acc.Currency) + "|"
 	}
 	accountStr = accountStr[:len(accountStr)-1]
 	_, err = file.Write([]byte(accountStr))
@@ -253,13 +422,21 @@ func (s *Service) ImportFromFile(path string) error {
 	for _, row := range rows {
 		cols := strings.Split(row, ";")
 		id, _ := strconv.ParseInt(cols[0], 10, 64)
-		phone := types.Phone(cols[1])
+		phone := storedPhone(types.Phone(cols[1]))
 		balance, _ := strconv.ParseInt(cols[2], 10, 64)
 
+		currency := types.DefaultCurrency
+		if len(cols) > 3 {
+			currency = types.Currency(cols[3])
+		}
+
 		account := &types.Account{
-			ID:      id,
-			Phone:   phone,
-			Balance: types.Money(balance),
+			ID:       id,
+			Phone:    phone,
+			Balance:  types.Money(balance),
+			Currency: currency,
+			Status:   types.AccountStatusActive,
+			Tier:     types.TierAnonymous,
 		}
 		s.accounts = append(s.accounts, account)
 	}
@@ -280,7 +457,7 @@ func (s *Service) Export(dir string) error {
 		}()
 		fileStr := ""
 		for _, account := range s.accounts {
-			fileStr += fmt.Sprint(account.ID) + ";" + string(account.Phone) + ";" + fmt.Sprint(account.Balance) + "\n"
+			fileStr += fmt.Sprint(account.ID) + ";" + string(account.Phone) + ";" + fmt.Sprint(account.Balance) + ";" + fmt.Sprint(account.Held) + ";" + string(account.Currency) + ";" + string(account.Status) + ";" + string(account.Tier) + ";" + fmt.Sprint(account.Pocketed) + "\n"
 		}
 		file.WriteString(fileStr[:len(fileStr)-1])
 	}
@@ -296,12 +473,12 @@ func (s *Service) Export(dir string) error {
 		}()
 		fileStr := ""
 		for _, payment := range s.payments {
-			fileStr += fmt.Sprint(payment.ID) + ";" + fmt.Sprint(payment.AccountID) + ";" + fmt.Sprint(payment.Amount) + ";" + fmt.Sprint(payment.Category) + ";" + fmt.Sprint(payment.Status) + "\n"
+			fileStr += paymentRow(payment) + "\n"
 		}
 		file.WriteString(fileStr[:len(fileStr)-1])
 	}
-	if len(s.favorites) > 0 {
-		file, err := os.OpenFile(dir+"/favorites.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if len(s.refunds) > 0 {
+		file, err := os.OpenFile(dir+"/refunds.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
 		defer func() {
 			if cerr := file.Close(); cerr != nil {
 				if err != nil {
@@ -310,100 +487,1075 @@ func (s *Service) Export(dir string) error {
 				}
 			}
 		}()
-
 		fileStr := ""
-		for _, favorite := range s.favorites {
-			fileStr += fmt.Sprint(favorite.ID) + ";" + fmt.Sprint(favorite.AccountID) + ";" + favorite.Name + ";" + fmt.Sprint(favorite.Amount) + ";" + fmt.Sprint(favorite.Category) + "\n"
+		for _, refund := range s.refunds {
+			fileStr += refund.ID + ";" + refund.PaymentID + ";" + fmt.Sprint(refund.Amount) + "\n"
 		}
 		file.WriteString(fileStr[:len(fileStr)-1])
 	}
-	return nil
-}
-
-func (s *Service) Import(dir string) error {
-	_, err := os.Stat(dir + "/accounts.dump")
-	if err == nil {
-		content, err := ioutil.ReadFile(dir + "/accounts.dump")
-		if err != nil {
-			return err
-		}
-		rows := strings.Split(string(content), "\n")
-		for _, row := range rows {
-			cols := strings.Split(row, ";")
-
-			id, err := strconv.ParseInt(cols[0], 10, 64)
-			if err != nil {
-				return err
-			}
-			balance, err := strconv.ParseInt(cols[2], 10, 64)
-			if err != nil {
-				return err
-			}
-			flag := true
-			for _, v := range s.accounts {
-				if v.ID == id {
-					flag = false
+	if len(s.holds) > 0 {
+		file, err := os.OpenFile(dir+"/holds.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+		defer func() {
+			if cerr := file.Close(); cerr != nil {
+				if err != nil {
+					err = cerr
+					log.Print(err)
 				}
 			}
-			if flag {
-				account := &types.Account{
-					ID:      id,
-					Phone:   types.Phone(cols[1]),
-					Balance: types.Money(balance),
+		}()
+		fileStr := ""
+		for _, hold := range s.holds {
+			fileStr += hold.ID + ";" + fmt.Sprint(hold.AccountID) + ";" + fmt.Sprint(hold.Amount) + ";" + string(hold.Status) + ";" + hold.PaymentID + ";" + fmt.Sprint(hold.Created.Unix()) + ";" + fmt.Sprint(hold.Expires.Unix()) + "\n"
+		}
+		file.WriteString(fileStr[:len(fileStr)-1])
+	}
+	if len(s.transfers) > 0 {
+		file, err := os.OpenFile(dir+"/transfers.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+		defer func() {
+			if cerr := file.Close(); cerr != nil {
+				if err != nil {
+					err = cerr
+					log.Print(err)
 				}
-				s.accounts = append(s.accounts, account)
 			}
+		}()
+		fileStr := ""
+		for _, transfer := range s.transfers {
+			fileStr += transfer.ID + ";" + fmt.Sprint(transfer.FromAccountID) + ";" + fmt.Sprint(transfer.ToAccountID) + ";" + fmt.Sprint(transfer.Amount) + ";" + fmt.Sprint(transfer.Created.Unix()) + "\n"
 		}
+		file.WriteString(fileStr[:len(fileStr)-1])
 	}
-	_, err = os.Stat(dir + "/payments.dump")
-	if err == nil {
-		content, err := ioutil.ReadFile(dir + "/payments.dump")
-		if err != nil {
-			return err
-		}
-		rows := strings.Split(string(content), "\n")
-		for _, row := range rows {
-			cols := strings.Split(row, ";")
-
-			id := cols[0]
-			if err != nil {
-				return err
-			}
-			accountID, err := strconv.ParseInt(cols[1], 10, 64)
-			if err != nil {
-				return err
+	if len(s.tierChanges) > 0 {
+		file, err := os.OpenFile(dir+"/tiers.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+		defer func() {
+			if cerr := file.Close(); cerr != nil {
+				if err != nil {
+					err = cerr
+					log.Print(err)
+				}
 			}
-			amount, err := strconv.ParseInt(cols[2], 10, 64)
-			if err != nil {
-				return err
+		}()
+		fileStr := ""
+		for _, change := range s.tierChanges {
+			fileStr += fmt.Sprint(change.AccountID) + ";" + string(change.From) + ";" + string(change.To) + ";" + escapeField(change.Reason) + ";" + fmt.Sprint(change.Changed.Unix()) + "\n"
+		}
+		file.WriteString(fileStr[:len(fileStr)-1])
+	}
+	if len(s.loyalty) > 0 {
+		file, err := os.OpenFile(dir+"/loyalty.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+		defer func() {
+			if cerr := file.Close(); cerr != nil {
+				if err != nil {
+					err = cerr
+					log.Print(err)
+				}
 			}
-			flag := true
-			for _, v := range s.payments {
-				if v.ID == id {
-					flag = false
+		}()
+		fileStr := ""
+		for _, entry := range s.loyalty {
+			fileStr += entry.ID + ";" + fmt.Sprint(entry.AccountID) + ";" + entry.PaymentID + ";" + fmt.Sprint(entry.Cashback) + ";" + fmt.Sprint(entry.Points) + ";" + fmt.Sprint(entry.Created.Unix()) + "\n"
+		}
+		file.WriteString(fileStr[:len(fileStr)-1])
+	}
+	if len(s.orders) > 0 {
+		file, err := os.OpenFile(dir+"/orders.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+		defer func() {
+			if cerr := file.Close(); cerr != nil {
+				if err != nil {
+					err = cerr
+					log.Print(err)
 				}
 			}
-			if flag {
-				data := &types.Payment{
-					ID:        id,
-					AccountID: accountID,
-					Amount:    types.Money(amount),
-					Category:  types.PaymentCategory(cols[3]),
-					Status:    types.PaymentStatus(cols[4]),
+		}()
+		fileStr := ""
+		for _, order := range s.orders {
+			fileStr += order.ID + ";" + order.FavoriteID + ";" + fmt.Sprint(order.AccountID) + ";" + string(order.Recurrence) + ";" + fmt.Sprint(order.Day) + ";" + fmt.Sprint(order.NextRun.Unix()) + ";" + strconv.FormatBool(order.Active) + ";" + fmt.Sprint(order.Amount) + ";" + escapeField(string(order.Category)) + ";" + fmt.Sprint(order.Attempts) + ";" + fmt.Sprint(order.RetryAt.Unix()) + "\n"
+		}
+		file.WriteString(fileStr[:len(fileStr)-1])
+	}
+	if len(s.orderRuns) > 0 {
+		file, err := os.OpenFile(dir+"/runs.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+		defer func() {
+			if cerr := file.Close(); cerr != nil {
+				if err != nil {
+					err = cerr
+					log.Print(err)
 				}
-				s.payments = append(s.payments, data)
 			}
+		}()
+		fileStr := ""
+		for _, run := range s.orderRuns {
+			fileStr += run.OrderID + ";" + fmt.Sprint(run.At.Unix()) + ";" + string(run.Status) + ";" + run.PaymentID + ";" + escapeField(run.Error) + ";" + fmt.Sprint(run.Attempt) + "\n"
 		}
-
+		file.WriteString(fileStr[:len(fileStr)-1])
 	}
-
-	_, err = os.Stat(dir + "/favorites.dump")
-	if err == nil {
-		content, err := ioutil.ReadFile(dir + "/favorites.dump")
-		if err != nil {
-			return err
+	if len(s.idempotency) > 0 {
+		file, err := os.OpenFile(dir+"/idempotency.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+		defer func() {
+			if cerr := file.Close(); cerr != nil {
+				if err != nil {
+					err = cerr
+					log.Print(err)
+				}
+			}
+		}()
+		fileStr := ""
+		for _, record := range s.idempotency {
+			fileStr += record.Key + ";" + escapeField(record.Request) + ";" + record.PaymentID + ";" + fmt.Sprint(record.Created.Unix()) + "\n"
 		}
-		rows := strings.Split(string(content), "\n")
+		file.WriteString(fileStr[:len(fileStr)-1])
+	}
+	if len(s.merchants) > 0 {
+		file, err := os.OpenFile(dir+"/merchants.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+		defer func() {
+			if cerr := file.Close(); cerr != nil {
+				if err != nil {
+					err = cerr
+					log.Print(err)
+				}
+			}
+		}()
+		fileStr := ""
+		for _, merchant := range s.merchants {
+			fileStr += fmt.Sprint(merchant.ID) + ";" + escapeField(merchant.Name) + ";" + escapeField(string(merchant.Category)) + ";" + string(merchant.Currency) + ";" + fmt.Sprint(merchant.Balance) + ";" + fmt.Sprint(merchant.Commission) + "\n"
+		}
+		file.WriteString(fileStr[:len(fileStr)-1])
+	}
+	if len(s.splits) > 0 {
+		file, err := os.OpenFile(dir+"/splits.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+		defer func() {
+			if cerr := file.Close(); cerr != nil {
+				if err != nil {
+					err = cerr
+					log.Print(err)
+				}
+			}
+		}()
+		fileStr := ""
+		for _, split := range s.splits {
+			fileStr += split.ID + ";" + fmt.Sprint(split.Amount) + ";" + escapeField(string(split.Category)) + ";" + string(split.Kind) + ";" + string(split.Status) + ";" + fmt.Sprint(split.Created.Unix()) + ";" + strings.Join(split.PaymentIDs, ",") + "\n"
+		}
+		file.WriteString(fileStr[:len(fileStr)-1])
+	}
+	if len(s.requests) > 0 {
+		file, err := os.OpenFile(dir+"/requests.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+		defer func() {
+			if cerr := file.Close(); cerr != nil {
+				if err != nil {
+					err = cerr
+					log.Print(err)
+				}
+			}
+		}()
+		fileStr := ""
+		for _, request := range s.requests {
+			fileStr += request.ID + ";" + fmt.Sprint(request.FromAccountID) + ";" + fmt.Sprint(request.ToAccountID) + ";" + fmt.Sprint(request.Amount) + ";" + escapeField(request.Note) + ";" + string(request.Status) + ";" + request.TransferID + ";" + fmt.Sprint(request.Created.Unix()) + ";" + fmt.Sprint(request.Expires.Unix()) + "\n"
+		}
+		file.WriteString(fileStr[:len(fileStr)-1])
+	}
+	if len(s.pockets) > 0 {
+		file, err := os.OpenFile(dir+"/pockets.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+		defer func() {
+			if cerr := file.Close(); cerr != nil {
+				if err != nil {
+					err = cerr
+					log.Print(err)
+				}
+			}
+		}()
+		fileStr := ""
+		for _, pocket := range s.pockets {
+			targetDate := int64(0)
+			if !pocket.TargetDate.IsZero() {
+				targetDate = pocket.TargetDate.Unix()
+			}
+			fileStr += pocket.ID + ";" + fmt.Sprint(pocket.AccountID) + ";" + escapeField(pocket.Name) + ";" + fmt.Sprint(pocket.Balance) + ";" + fmt.Sprint(pocket.Target) + ";" + fmt.Sprint(targetDate) + ";" + fmt.Sprint(pocket.RoundUp) + "\n"
+		}
+		file.WriteString(fileStr[:len(fileStr)-1])
+	}
+	if len(s.payouts) > 0 {
+		file, err := os.OpenFile(dir+"/payouts.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+		defer func() {
+			if cerr := file.Close(); cerr != nil {
+				if err != nil {
+					err = cerr
+					log.Print(err)
+				}
+			}
+		}()
+		fileStr := ""
+		for _, batch := range s.payouts {
+			for _, settlement := range batch.Settlements {
+				fileStr += settlement.ID + ";" + batch.ID + ";" + fmt.Sprint(batch.Created.Unix()) + ";" + fmt.Sprint(settlement.MerchantID) + ";" + fmt.Sprint(settlement.Payments) + ";" + fmt.Sprint(settlement.Gross) + ";" + fmt.Sprint(settlement.Refunded) + ";" + fmt.Sprint(settlement.Fee) + ";" + fmt.Sprint(settlement.Net) + ";" + string(settlement.Currency) + "\n"
+			}
+		}
+		file.WriteString(fileStr[:len(fileStr)-1])
+	}
+	if len(s.favorites) > 0 {
+		file, err := os.OpenFile(dir+"/favorites.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+		defer func() {
+			if cerr := file.Close(); cerr != nil {
+				if err != nil {
+					err = cerr
+					log.Print(err)
+				}
+			}
+		}()
+
+		fileStr := ""
+		for _, favorite := range s.favorites {
+			fileStr += fmt.Sprint(favorite.ID) + ";" + fmt.Sprint(favorite.AccountID) + ";" + escapeField(favorite.Name) + ";" + fmt.Sprint(favorite.Amount) + ";" + escapeField(string(favorite.Category)) + ";" + fmt.Sprint(favorite.Position) + ";" + fmt.Sprint(favorite.MinAmount) + ";" + fmt.Sprint(favorite.MaxAmount) + ";" + encodeTemplateFields(favorite.Fields) + ";" + fmt.Sprint(favorite.MerchantID) + "\n"
+		}
+		file.WriteString(fileStr[:len(fileStr)-1])
+	}
+	return nil
+}
+
+func (s *Service) Import(dir string) error {
+	_, err := os.Stat(dir + "/accounts.dump")
+	if err == nil {
+		content, err := ioutil.ReadFile(dir + "/accounts.dump")
+		if err != nil {
+			return err
+		}
+		rows := strings.Split(string(content), "\n")
+		for _, row := range rows {
+			cols := strings.Split(row, ";")
+
+			id, err := strconv.ParseInt(cols[0], 10, 64)
+			if err != nil {
+				return err
+			}
+			balance, err := strconv.ParseInt(cols[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			held := int64(0)
+			if len(cols) > 3 {
+				held, err = strconv.ParseInt(cols[3], 10, 64)
+				if err != nil {
+					return err
+				}
+			}
+			currency := types.DefaultCurrency
+			if len(cols) > 4 {
+				currency = types.Currency(cols[4])
+			}
+			status := types.AccountStatusActive
+			if len(cols) > 5 {
+				status = types.AccountStatus(cols[5])
+			}
+			tier := types.TierAnonymous
+			if len(cols) > 6 {
+				tier = types.Tier(cols[6])
+			}
+			pocketed := int64(0)
+			if len(cols) > 7 {
+				pocketed, err = strconv.ParseInt(cols[7], 10, 64)
+				if err != nil {
+					return err
+				}
+			}
+			flag := true
+			for _, v := range s.accounts {
+				if v.ID == id {
+					flag = false
+				}
+			}
+			if flag {
+				account := &types.Account{
+					ID:       id,
+					Phone:    storedPhone(types.Phone(cols[1])),
+					Balance:  types.Money(balance),
+					Held:     types.Money(held),
+					Currency: currency,
+					Status:   status,
+					Tier:     tier,
+					Pocketed: types.Money(pocketed),
+				}
+				s.accounts = append(s.accounts, account)
+			}
+		}
+	}
+	_, err = os.Stat(dir + "/payments.dump")
+	if err == nil {
+		content, err := ioutil.ReadFile(dir + "/payments.dump")
+		if err != nil {
+			return err
+		}
+		rows := strings.Split(string(content), "\n")
+		for _, row := range rows {
+			cols := strings.Split(row, ";")
+
+			id := cols[0]
+			if err != nil {
+				return err
+			}
+			accountID, err := strconv.ParseInt(cols[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			amount, err := strconv.ParseInt(cols[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			refunded := int64(0)
+			if len(cols) > 5 {
+				refunded, err = strconv.ParseInt(cols[5], 10, 64)
+				if err != nil {
+					return err
+				}
+			}
+			currency := types.DefaultCurrency
+			if len(cols) > 6 {
+				currency = types.Currency(cols[6])
+			}
+			originalAmount := int64(0)
+			originalCurrency := ""
+			exchangeRate := ""
+			if len(cols) > 9 {
+				originalAmount, err = strconv.ParseInt(cols[7], 10, 64)
+				if err != nil {
+					return err
+				}
+				originalCurrency = cols[8]
+				exchangeRate = cols[9]
+			}
+			created := time.Time{}
+			if len(cols) > 10 {
+				unix, err := strconv.ParseInt(cols[10], 10, 64)
+				if err != nil {
+					return err
+				}
+				created = time.Unix(unix, 0)
+			}
+			fee := int64(0)
+			if len(cols) > 11 {
+				fee, err = strconv.ParseInt(cols[11], 10, 64)
+				if err != nil {
+					return err
+				}
+			}
+			var metadata map[string]string
+			if len(cols) > 12 {
+				metadata, err = decodeMetadata(cols[12])
+				if err != nil {
+					return err
+				}
+			}
+			merchantID := int64(0)
+			if len(cols) > 13 {
+				merchantID, err = strconv.ParseInt(cols[13], 10, 64)
+				if err != nil {
+					return err
+				}
+			}
+			settlementID := ""
+			if len(cols) > 14 {
+				settlementID = cols[14]
+			}
+			// categories are escaped since the tags and note columns were added
+			category := cols[3]
+			var tags []string
+			note := ""
+			if len(cols) > 16 {
+				category, err = unescapeField(cols[3])
+				if err != nil {
+					return err
+				}
+				tags, err = decodeTags(cols[15])
+				if err != nil {
+					return err
+				}
+				note, err = unescapeField(cols[16])
+				if err != nil {
+					return err
+				}
+			}
+			splitID := ""
+			if len(cols) > 17 {
+				splitID = cols[17]
+			}
+			feeAccountID := int64(0)
+			if len(cols) > 18 {
+				feeAccountID, err = strconv.ParseInt(cols[18], 10, 64)
+				if err != nil {
+					return err
+				}
+			}
+			flag := true
+			for _, v := range s.payments {
+				if v.ID == id {
+					flag = false
+				}
+			}
+			if flag {
+				data := &types.Payment{
+					ID:        id,
+					AccountID: accountID,
+					Amount:    types.Money(amount),
+					Currency:  currency,
+					Category:  types.PaymentCategory(category),
+					Status:    types.PaymentStatus(cols[4]),
+					Refunded:  types.Money(refunded),
+					Fee:       types.Money(fee),
+					Created:   created,
+					Metadata:  metadata,
+					Tags:      tags,
+					Note:      note,
+
+					MerchantID:   merchantID,
+					SettlementID: settlementID,
+
+					SplitID: splitID,
+
+					FeeAccountID: feeAccountID,
+
+					OriginalAmount:   types.Money(originalAmount),
+					OriginalCurrency: types.Currency(originalCurrency),
+					ExchangeRate:     exchangeRate,
+				}
+				s.payments = append(s.payments, data)
+			}
+		}
+
+	}
+
+	_, err = os.Stat(dir + "/refunds.dump")
+	if err == nil {
+		content, err := ioutil.ReadFile(dir + "/refunds.dump")
+		if err != nil {
+			return err
+		}
+		rows := strings.Split(string(content), "\n")
+		for _, row := range rows {
+			cols := strings.Split(row, ";")
+
+			amount, err := strconv.ParseInt(cols[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			flag := true
+			for _, v := range s.refunds {
+				if v.ID == cols[0] {
+					flag = false
+				}
+			}
+			if flag {
+				s.refunds = append(s.refunds, &types.Refund{
+					ID:        cols[0],
+					PaymentID: cols[1],
+					Amount:    types.Money(amount),
+				})
+			}
+		}
+	}
+
+	_, err = os.Stat(dir + "/holds.dump")
+	if err == nil {
+		content, err := ioutil.ReadFile(dir + "/holds.dump")
+		if err != nil {
+			return err
+		}
+		rows := strings.Split(string(content), "\n")
+		for _, row := range rows {
+			cols := strings.Split(row, ";")
+
+			accountID, err := strconv.ParseInt(cols[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			amount, err := strconv.ParseInt(cols[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			created, err := strconv.ParseInt(cols[5], 10, 64)
+			if err != nil {
+				return err
+			}
+			expires, err := strconv.ParseInt(cols[6], 10, 64)
+			if err != nil {
+				return err
+			}
+			flag := true
+			for _, v := range s.holds {
+				if v.ID == cols[0] {
+					flag = false
+				}
+			}
+			if flag {
+				s.holds = append(s.holds, &types.Hold{
+					ID:        cols[0],
+					AccountID: accountID,
+					Amount:    types.Money(amount),
+					Status:    types.HoldStatus(cols[3]),
+					PaymentID: cols[4],
+					Created:   time.Unix(created, 0),
+					Expires:   time.Unix(expires, 0),
+				})
+			}
+		}
+	}
+
+	_, err = os.Stat(dir + "/transfers.dump")
+	if err == nil {
+		content, err := ioutil.ReadFile(dir + "/transfers.dump")
+		if err != nil {
+			return err
+		}
+		rows := strings.Split(string(content), "\n")
+		for _, row := range rows {
+			cols := strings.Split(row, ";")
+
+			fromAccountID, err := strconv.ParseInt(cols[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			toAccountID, err := strconv.ParseInt(cols[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			amount, err := strconv.ParseInt(cols[3], 10, 64)
+			if err != nil {
+				return err
+			}
+			created, err := strconv.ParseInt(cols[4], 10, 64)
+			if err != nil {
+				return err
+			}
+			flag := true
+			for _, v := range s.transfers {
+				if v.ID == cols[0] {
+					flag = false
+				}
+			}
+			if flag {
+				s.transfers = append(s.transfers, &types.Transfer{
+					ID:            cols[0],
+					FromAccountID: fromAccountID,
+					ToAccountID:   toAccountID,
+					Amount:        types.Money(amount),
+					Created:       time.Unix(created, 0),
+				})
+			}
+		}
+	}
+
+	_, err = os.Stat(dir + "/tiers.dump")
+	if err == nil {
+		content, err := ioutil.ReadFile(dir + "/tiers.dump")
+		if err != nil {
+			return err
+		}
+		rows := strings.Split(string(content), "\n")
+		for _, row := range rows {
+			cols := strings.Split(row, ";")
+
+			accountID, err := strconv.ParseInt(cols[0], 10, 64)
+			if err != nil {
+				return err
+			}
+			reason, err := unescapeField(cols[3])
+			if err != nil {
+				return err
+			}
+			changed, err := strconv.ParseInt(cols[4], 10, 64)
+			if err != nil {
+				return err
+			}
+			flag := true
+			for _, v := range s.tierChanges {
+				if v.AccountID == accountID && v.To == types.Tier(cols[2]) {
+					flag = false
+				}
+			}
+			if flag {
+				s.tierChanges = append(s.tierChanges, &types.TierChange{
+					AccountID: accountID,
+					From:      types.Tier(cols[1]),
+					To:        types.Tier(cols[2]),
+					Reason:    reason,
+					Changed:   time.Unix(changed, 0),
+				})
+			}
+		}
+	}
+
+	_, err = os.Stat(dir + "/loyalty.dump")
+	if err == nil {
+		content, err := ioutil.ReadFile(dir + "/loyalty.dump")
+		if err != nil {
+			return err
+		}
+		rows := strings.Split(string(content), "\n")
+		for _, row := range rows {
+			cols := strings.Split(row, ";")
+
+			accountID, err := strconv.ParseInt(cols[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			cashback, err := strconv.ParseInt(cols[3], 10, 64)
+			if err != nil {
+				return err
+			}
+			points, err := strconv.ParseInt(cols[4], 10, 64)
+			if err != nil {
+				return err
+			}
+			created, err := strconv.ParseInt(cols[5], 10, 64)
+			if err != nil {
+				return err
+			}
+			flag := true
+			for _, v := range s.loyalty {
+				if v.ID == cols[0] {
+					flag = false
+				}
+			}
+			if flag {
+				s.loyalty = append(s.loyalty, &types.LoyaltyEntry{
+					ID:        cols[0],
+					AccountID: accountID,
+					PaymentID: cols[2],
+					Cashback:  types.Money(cashback),
+					Points:    points,
+					Created:   time.Unix(created, 0),
+				})
+			}
+		}
+	}
+
+	_, err = os.Stat(dir + "/orders.dump")
+	if err == nil {
+		content, err := ioutil.ReadFile(dir + "/orders.dump")
+		if err != nil {
+			return err
+		}
+		rows := strings.Split(string(content), "\n")
+		for _, row := range rows {
+			cols := strings.Split(row, ";")
+
+			accountID, err := strconv.ParseInt(cols[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			day, err := strconv.Atoi(cols[4])
+			if err != nil {
+				return err
+			}
+			nextRun, err := strconv.ParseInt(cols[5], 10, 64)
+			if err != nil {
+				return err
+			}
+			if !validRecurrence(types.Recurrence(cols[3])) {
+				return ErrInvalidSchedule
+			}
+			active, err := strconv.ParseBool(cols[6])
+			if err != nil {
+				return err
+			}
+			amount := int64(0)
+			category := ""
+			attempts := 0
+			retryAt := time.Time{}
+			if len(cols) > 10 {
+				amount, err = strconv.ParseInt(cols[7], 10, 64)
+				if err != nil {
+					return err
+				}
+				category, err = unescapeField(cols[8])
+				if err != nil {
+					return err
+				}
+				attempts, err = strconv.Atoi(cols[9])
+				if err != nil {
+					return err
+				}
+				if attempts > 0 {
+					unix, err := strconv.ParseInt(cols[10], 10, 64)
+					if err != nil {
+						return err
+					}
+					retryAt = time.Unix(unix, 0)
+				}
+			}
+			flag := true
+			for _, v := range s.orders {
+				if v.ID == cols[0] {
+					flag = false
+				}
+			}
+			if flag {
+				s.orders = append(s.orders, &types.StandingOrder{
+					ID:         cols[0],
+					FavoriteID: cols[1],
+					AccountID:  accountID,
+					Recurrence: types.Recurrence(cols[3]),
+					Day:        day,
+					Amount:     types.Money(amount),
+					Category:   types.PaymentCategory(category),
+					NextRun:    time.Unix(nextRun, 0),
+					Active:     active,
+					Attempts:   attempts,
+					RetryAt:    retryAt,
+				})
+			}
+		}
+	}
+
+	_, err = os.Stat(dir + "/runs.dump")
+	if err == nil {
+		content, err := ioutil.ReadFile(dir + "/runs.dump")
+		if err != nil {
+			return err
+		}
+		rows := strings.Split(string(content), "\n")
+		for _, row := range rows {
+			cols := strings.Split(row, ";")
+
+			at, err := strconv.ParseInt(cols[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			runErr, err := unescapeField(cols[4])
+			if err != nil {
+				return err
+			}
+			attempt := 1
+			if len(cols) > 5 {
+				attempt, err = strconv.Atoi(cols[5])
+				if err != nil {
+					return err
+				}
+			}
+			flag := true
+			for _, v := range s.orderRuns {
+				if v.OrderID == cols[0] && v.At.Unix() == at && v.Attempt == attempt {
+					flag = false
+				}
+			}
+			if flag {
+				s.orderRuns = append(s.orderRuns, &types.OrderRun{
+					OrderID:   cols[0],
+					At:        time.Unix(at, 0),
+					Attempt:   attempt,
+					Status:    types.RunStatus(cols[2]),
+					PaymentID: cols[3],
+					Error:     runErr,
+				})
+			}
+		}
+	}
+
+	_, err = os.Stat(dir + "/idempotency.dump")
+	if err == nil {
+		content, err := ioutil.ReadFile(dir + "/idempotency.dump")
+		if err != nil {
+			return err
+		}
+		rows := strings.Split(string(content), "\n")
+		for _, row := range rows {
+			cols := strings.Split(row, ";")
+
+			request, err := unescapeField(cols[1])
+			if err != nil {
+				return err
+			}
+			created, err := strconv.ParseInt(cols[3], 10, 64)
+			if err != nil {
+				return err
+			}
+			flag := true
+			for _, v := range s.idempotency {
+				if v.Key == cols[0] {
+					flag = false
+				}
+			}
+			if flag {
+				s.idempotency = append(s.idempotency, &types.IdempotencyRecord{
+					Key:       cols[0],
+					Request:   request,
+					PaymentID: cols[2],
+					Created:   time.Unix(created, 0),
+				})
+			}
+		}
+	}
+
+	_, err = os.Stat(dir + "/merchants.dump")
+	if err == nil {
+		content, err := ioutil.ReadFile(dir + "/merchants.dump")
+		if err != nil {
+			return err
+		}
+		rows := strings.Split(string(content), "\n")
+		for _, row := range rows {
+			cols := strings.Split(row, ";")
+
+			id, err := strconv.ParseInt(cols[0], 10, 64)
+			if err != nil {
+				return err
+			}
+			name, err := unescapeField(cols[1])
+			if err != nil {
+				return err
+			}
+			category, err := unescapeField(cols[2])
+			if err != nil {
+				return err
+			}
+			balance, err := strconv.ParseInt(cols[4], 10, 64)
+			if err != nil {
+				return err
+			}
+			commission := int64(0)
+			if len(cols) > 5 {
+				commission, err = strconv.ParseInt(cols[5], 10, 64)
+				if err != nil {
+					return err
+				}
+			}
+			flag := true
+			for _, v := range s.merchants {
+				if v.ID == id {
+					flag = false
+				}
+			}
+			if flag {
+				s.merchants = append(s.merchants, &types.Merchant{
+					ID:         id,
+					Name:       name,
+					Category:   types.PaymentCategory(category),
+					Currency:   types.Currency(cols[3]),
+					Balance:    types.Money(balance),
+					Commission: commission,
+				})
+			}
+			if id > s.nextMerchantID {
+				s.nextMerchantID = id
+			}
+		}
+	}
+
+	_, err = os.Stat(dir + "/splits.dump")
+	if err == nil {
+		content, err := ioutil.ReadFile(dir + "/splits.dump")
+		if err != nil {
+			return err
+		}
+		rows := strings.Split(string(content), "\n")
+		for _, row := range rows {
+			cols := strings.Split(row, ";")
+
+			amount, err := strconv.ParseInt(cols[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			category, err := unescapeField(cols[2])
+			if err != nil {
+				return err
+			}
+			created, err := strconv.ParseInt(cols[5], 10, 64)
+			if err != nil {
+				return err
+			}
+			flag := true
+			for _, v := range s.splits {
+				if v.ID == cols[0] {
+					flag = false
+				}
+			}
+			if flag {
+				s.splits = append(s.splits, &types.Split{
+					ID:         cols[0],
+					Amount:     types.Money(amount),
+					Category:   types.PaymentCategory(category),
+					Kind:       types.SplitKind(cols[3]),
+					Status:     types.PaymentStatus(cols[4]),
+					PaymentIDs: strings.Split(cols[6], ","),
+					Created:    time.Unix(created, 0),
+				})
+			}
+		}
+	}
+
+	_, err = os.Stat(dir + "/requests.dump")
+	if err == nil {
+		content, err := ioutil.ReadFile(dir + "/requests.dump")
+		if err != nil {
+			return err
+		}
+		rows := strings.Split(string(content), "\n")
+		for _, row := range rows {
+			cols := strings.Split(row, ";")
+
+			fromAccountID, err := strconv.ParseInt(cols[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			toAccountID, err := strconv.ParseInt(cols[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			amount, err := strconv.ParseInt(cols[3], 10, 64)
+			if err != nil {
+				return err
+			}
+			note, err := unescapeField(cols[4])
+			if err != nil {
+				return err
+			}
+			created, err := strconv.ParseInt(cols[7], 10, 64)
+			if err != nil {
+				return err
+			}
+			expires, err := strconv.ParseInt(cols[8], 10, 64)
+			if err != nil {
+				return err
+			}
+			flag := true
+			for _, v := range s.requests {
+				if v.ID == cols[0] {
+					flag = false
+				}
+			}
+			if flag {
+				s.requests = append(s.requests, &types.MoneyRequest{
+					ID:            cols[0],
+					FromAccountID: fromAccountID,
+					ToAccountID:   toAccountID,
+					Amount:        types.Money(amount),
+					Note:          note,
+					Status:        types.RequestStatus(cols[5]),
+					TransferID:    cols[6],
+					Created:       time.Unix(created, 0),
+					Expires:       time.Unix(expires, 0),
+				})
+			}
+		}
+	}
+
+	_, err = os.Stat(dir + "/pockets.dump")
+	if err == nil {
+		content, err := ioutil.ReadFile(dir + "/pockets.dump")
+		if err != nil {
+			return err
+		}
+		rows := strings.Split(string(content), "\n")
+		for _, row := range rows {
+			cols := strings.Split(row, ";")
+
+			accountID, err := strconv.ParseInt(cols[1], 10, 64)
+			if err != nil {
+				return err
+			}
+			name, err := unescapeField(cols[2])
+			if err != nil {
+				return err
+			}
+			amounts := []int64{}
+			for _, col := range cols[3:7] {
+				amount, err := strconv.ParseInt(col, 10, 64)
+				if err != nil {
+					return err
+				}
+				amounts = append(amounts, amount)
+			}
+			targetDate := time.Time{}
+			if amounts[2] != 0 {
+				targetDate = time.Unix(amounts[2], 0)
+			}
+			flag := true
+			for _, v := range s.pockets {
+				if v.ID == cols[0] {
+					flag = false
+				}
+			}
+			if flag {
+				s.pockets = append(s.pockets, &types.Pocket{
+					ID:         cols[0],
+					AccountID:  accountID,
+					Name:       name,
+					Balance:    types.Money(amounts[0]),
+					Target:     types.Money(amounts[1]),
+					TargetDate: targetDate,
+					RoundUp:    types.Money(amounts[3]),
+				})
+			}
+		}
+	}
+
+	_, err = os.Stat(dir + "/payouts.dump")
+	if err == nil {
+		content, err := ioutil.ReadFile(dir + "/payouts.dump")
+		if err != nil {
+			return err
+		}
+		rows := strings.Split(string(content), "\n")
+		for _, row := range rows {
+			cols := strings.Split(row, ";")
+
+			created, err := strconv.ParseInt(cols[2], 10, 64)
+			if err != nil {
+				return err
+			}
+			merchantID, err := strconv.ParseInt(cols[3], 10, 64)
+			if err != nil {
+				return err
+			}
+			payments, err := strconv.Atoi(cols[4])
+			if err != nil {
+				return err
+			}
+			amounts := []int64{}
+			for _, col := range cols[5:9] {
+				amount, err := strconv.ParseInt(col, 10, 64)
+				if err != nil {
+					return err
+				}
+				amounts = append(amounts, amount)
+			}
+
+			var batch *types.PayoutBatch
+			for _, v := range s.payouts {
+				if v.ID == cols[1] {
+					batch = v
+				}
+			}
+			if batch == nil {
+				batch = &types.PayoutBatch{ID: cols[1], Created: time.Unix(created, 0)}
+				s.payouts = append(s.payouts, batch)
+			}
+			flag := true
+			for _, v := range batch.Settlements {
+				if v.ID == cols[0] {
+					flag = false
+				}
+			}
+			if flag {
+				batch.Settlements = append(batch.Settlements, types.Settlement{
+					ID:         cols[0],
+					BatchID:    batch.ID,
+					MerchantID: merchantID,
+					Payments:   payments,
+					Gross:      types.Money(amounts[0]),
+					Refunded:   types.Money(amounts[1]),
+					Fee:        types.Money(amounts[2]),
+					Net:        types.Money(amounts[3]),
+					Currency:   types.Currency(cols[9]),
+				})
+			}
+		}
+	}
+
+	_, err = os.Stat(dir + "/favorites.dump")
+	if err == nil {
+		content, err := ioutil.ReadFile(dir + "/favorites.dump")
+		if err != nil {
+			return err
+		}
+		rows := strings.Split(string(content), "\n")
 		for _, row := range rows {
 			cols := strings.Split(row, ";")
 
@@ -419,6 +1571,49 @@ func (s *Service) Import(dir string) error {
 			if err != nil {
 				return err
 			}
+			// names are escaped since the position column was added
+			name := cols[2]
+			position := 0
+			if len(cols) > 5 {
+				name, err = unescapeField(cols[2])
+				if err != nil {
+					return err
+				}
+				position, err = strconv.Atoi(cols[5])
+				if err != nil {
+					return err
+				}
+			}
+			minAmount := int64(0)
+			maxAmount := int64(0)
+			var fields []types.TemplateField
+			if len(cols) > 8 {
+				minAmount, err = strconv.ParseInt(cols[6], 10, 64)
+				if err != nil {
+					return err
+				}
+				maxAmount, err = strconv.ParseInt(cols[7], 10, 64)
+				if err != nil {
+					return err
+				}
+				fields, err = decodeTemplateFields(cols[8])
+				if err != nil {
+					return err
+				}
+			}
+			// categories are escaped since the merchant column was added
+			category := cols[4]
+			merchantID := int64(0)
+			if len(cols) > 9 {
+				category, err = unescapeField(cols[4])
+				if err != nil {
+					return err
+				}
+				merchantID, err = strconv.ParseInt(cols[9], 10, 64)
+				if err != nil {
+					return err
+				}
+			}
 			flag := true
 			for _, v := range s.favorites {
 				if v.ID == id {
@@ -429,9 +1624,15 @@ func (s *Service) Import(dir string) error {
 				data := &types.Favorite{
 					ID:        id,
 					AccountID: accountID,
-					Name:      cols[2],
+					Name:      name,
 					Amount:    types.Money(amount),
-					Category:  types.PaymentCategory(cols[4]),
+					Category:  types.PaymentCategory(category),
+					Position:  position,
+					MinAmount: types.Money(minAmount),
+					MaxAmount: types.Money(maxAmount),
+					Fields:    fields,
+
+					MerchantID: merchantID,
 				}
 				s.favorites = append(s.favorites, data)
 			}
@@ -449,19 +1650,13 @@ func (s *Service) ExportAccountHistory(accountID int64) ([]types.Payment, error)
 	history := []types.Payment{}
 	for _, payment := range s.payments {
 		if payment.AccountID == account.ID {
-			curPayment := types.Payment{
-				ID:        payment.ID,
-				AccountID: payment.AccountID,
-				Amount:    payment.Amount,
-				Category:  payment.Category,
-				Status:    payment.Status,
-			}
-			history = append(history, curPayment)
+			history = append(history, *payment)
 		}
 	}
 	return history, nil
 }
 
+// HistoryToFile writes payments to file in the payments.dump format of Export
 func HistoryToFile(payments []types.Payment, filename string) error {
 	if len(payments) < 1 {
 		return nil
@@ -477,12 +1672,17 @@ func HistoryToFile(payments []types.Payment, filename string) error {
 	}()
 	fileStr := ""
 	for _, payment := range payments {
-		fileStr += fmt.Sprint(payment.ID) + ";" + fmt.Sprint(payment.AccountID) + ";" + fmt.Sprint(payment.Amount) + ";" + fmt.Sprint(payment.Category) + ";" + fmt.Sprint(payment.Status) + "\n"
+		fileStr += paymentRow(&payment) + "\n"
 	}
 	file.WriteString(fileStr[:len(fileStr)-1])
 	return nil
 }
 
+// paymentRow formats payment as a payments.dump row
+func paymentRow(payment *types.Payment) string {
+	return fmt.Sprint(payment.ID) + ";" + fmt.Sprint(payment.AccountID) + ";" + fmt.Sprint(payment.Amount) + ";" + escapeField(string(payment.Category)) + ";" + fmt.Sprint(payment.Status) + ";" + fmt.Sprint(payment.Refunded) + ";" + string(payment.Currency) + ";" + fmt.Sprint(payment.OriginalAmount) + ";" + string(payment.OriginalCurrency) + ";" + payment.ExchangeRate + ";" + fmt.Sprint(payment.Created.Unix()) + ";" + fmt.Sprint(payment.Fee) + ";" + encodeMetadata(payment.Metadata) + ";" + fmt.Sprint(payment.MerchantID) + ";" + payment.SettlementID + ";" + encodeTags(payment.Tags) + ";" + escapeField(payment.Note) + ";" + payment.SplitID + ";" + fmt.Sprint(payment.FeeAccountID)
+}
+
 func (s *Service) HistoryToFiles(payments []types.Payment, dir string, records int) error {
 	if len(payments) < 1 {
 		return nil
@@ -553,6 +1753,51 @@ func (s *Service) SumPayments(goroutines int) types.Money {
 	return types.Money(sum)
 }
 
+// SumPaymentsChecked works like SumPayments, but returns money.ErrOverflow
+// instead of a wrapped around sum.
+func (s *Service) SumPaymentsChecked(goroutines int) (types.Money, error) {
+	if goroutines < 1 {
+		goroutines = 1
+	}
+
+	wg := sync.WaitGroup{}
+	mu := sync.Mutex{}
+	sum := types.Money(0)
+	var sumErr error
+	part := len(s.payments)/goroutines + 1
+	for i := 0; i < len(s.payments); i += part {
+		end := i + part
+		if end > len(s.payments) {
+			end = len(s.payments)
+		}
+		wg.Add(1)
+		go func(payments []*types.Payment) {
+			defer wg.Done()
+			val := types.Money(0)
+			var err error
+			for _, payment := range payments {
+				val, err = money.Add(val, payment.Amount)
+				if err != nil {
+					break
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				sum, err = money.Add(sum, val)
+			}
+			if err != nil {
+				sumErr = err
+			}
+		}(s.payments[i:end])
+	}
+	wg.Wait()
+	if sumErr != nil {
+		return 0, sumErr
+	}
+	return sum, nil
+}
+
 func (s *Service) FilterPayments(accountID int64, goroutines int) ([]types.Payment, error) {
 
 	if goroutines == 0 || goroutines == 1 {
@@ -3,9 +3,12 @@ package wallet
 import (
 	"fmt"
 	"log"
+	"math"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/fm2901/wallet/pkg/money"
 	"github.com/fm2901/wallet/pkg/types"
 	"github.com/google/uuid"
 )
@@ -34,6 +37,18 @@ var defaultTestAccount = testAccount{
 	},
 }
 
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
 func newTestService() *testService {
 	return &testService{Service: &Service{}}
 }
@@ -470,3 +485,146 @@ func BenchmarkSumPaymentsWithProgress(b *testing.B) {
 		b.StartTimer()
 	}
 }
+
+func Test_RegisterAccountWithCurrency_success(t *testing.T) {
+	s := newTestService()
+	account, err := s.RegisterAccountWithCurrency("992000000001", types.CurrencyUSD)
+	if err != nil {
+		t.Errorf("RegisterAccountWithCurrency(): error = %v", err)
+		return
+	}
+
+	if account.Currency != types.CurrencyUSD {
+		t.Errorf("RegisterAccountWithCurrency(): wrong currency, account = %v", account)
+		return
+	}
+
+	err = s.DepositAmount(account.ID, types.Amount{Value: 100_00, Currency: types.CurrencyUSD})
+	if err != nil {
+		t.Errorf("DepositAmount(): error = %v", err)
+		return
+	}
+
+	payment, err := s.PayAmount(account.ID, types.Amount{Value: 10_00, Currency: types.CurrencyUSD}, "auto")
+	if err != nil {
+		t.Errorf("PayAmount(): error = %v", err)
+		return
+	}
+
+	if payment.Currency != types.CurrencyUSD {
+		t.Errorf("PayAmount(): wrong currency, payment = %v", payment)
+		return
+	}
+}
+
+func Test_RegisterAccountWithCurrency_fail(t *testing.T) {
+	s := newTestService()
+	_, err := s.RegisterAccountWithCurrency("992000000001", "XYZ")
+	if err != ErrUnknownCurrency {
+		t.Errorf("RegisterAccountWithCurrency(): must return ErrUnknownCurrency, returned = %v", err)
+		return
+	}
+}
+
+func Test_PayAmount_fail(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.PayAmount(account.ID, types.Amount{Value: 10_00, Currency: types.CurrencyRUB}, "auto")
+	if err != ErrCurrencyMismatch {
+		t.Errorf("PayAmount(): must return ErrCurrencyMismatch, returned = %v", err)
+		return
+	}
+
+	err = s.DepositAmount(account.ID, types.Amount{Value: 10_00, Currency: types.CurrencyUSD})
+	if err != ErrCurrencyMismatch {
+		t.Errorf("DepositAmount(): must return ErrCurrencyMismatch, returned = %v", err)
+		return
+	}
+}
+
+func Test_Import_currency(t *testing.T) {
+	s := newTestService()
+	account, err := s.RegisterAccountWithCurrency("992000000001", types.CurrencyRUB)
+	if err != nil {
+		t.Errorf("RegisterAccountWithCurrency(): error = %v", err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+
+	got, err := imported.FindAccountByID(account.ID)
+	if err != nil {
+		t.Errorf("FindAccountByID(): error = %v", err)
+		return
+	}
+
+	if !reflect.DeepEqual(account, got) {
+		t.Errorf("Import(): wrong account imported = %v", got)
+		return
+	}
+}
+
+func Test_SumPaymentsChecked(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < 10; i++ {
+		_, err := s.Pay(account.ID, 1_000_00, "mobile")
+		if err != nil {
+			t.Errorf("Pay(): error = %v", err)
+			return
+		}
+	}
+
+	sum, err := s.SumPaymentsChecked(3)
+	if err != nil {
+		t.Errorf("SumPaymentsChecked(): error = %v", err)
+		return
+	}
+	if sum != 11_000_00 {
+		t.Errorf("SumPaymentsChecked(): wrong sum = %v", sum)
+		return
+	}
+
+	s.payments = append(s.payments, &types.Payment{Amount: math.MaxInt64})
+	_, err = s.SumPaymentsChecked(3)
+	if err != money.ErrOverflow {
+		t.Errorf("SumPaymentsChecked(): must return ErrOverflow, returned = %v", err)
+		return
+	}
+}
+
+func Test_Deposit_overflow(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Deposit(account.ID, math.MaxInt64)
+	if err != money.ErrOverflow {
+		t.Errorf("Deposit(): must return ErrOverflow, returned = %v", err)
+		return
+	}
+}
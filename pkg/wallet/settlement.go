@@ -0,0 +1,168 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/fm2901/wallet/pkg/money"
+	"github.com/fm2901/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrNothingToSettle = errors.New("nothing to settle")
+var ErrInvalidCommission = errors.New("invalid commission")
+var ErrPayoutNotFound = errors.New("payout batch not found")
+
+// SetMerchantCommission sets basis points retained from merchant payouts, the
+// commission is credited to the revenue account
+func (s *Service) SetMerchantCommission(merchantID int64, commission int64) error {
+	merchant, err := s.FindMerchantByID(merchantID)
+	if err != nil {
+		return err
+	}
+
+	if commission < 0 || commission > 10_000 {
+		return ErrInvalidCommission
+	}
+	merchant.Commission = commission
+	return nil
+}
+
+// Settle pays out balances of all merchants that are owed money and marks
+// their unsettled payments with the settlement, so no payment is settled
+// twice. Refunds and rejections after a settlement are deducted from the next
+// one, a merchant owing money is not settled until new payments cover it.
+// Every settlement is computed before any of them is applied, so on error
+// nothing is changed.
+func (s *Service) Settle() (*types.PayoutBatch, error) {
+	batch := &types.PayoutBatch{
+		ID:      uuid.New().String(),
+		Created: s.now(),
+	}
+
+	var revenue *types.Account
+	fees := types.Money(0)
+	merchants := []*types.Merchant{}
+	payments := [][]*types.Payment{}
+	for _, merchant := range s.merchants {
+		if merchant.Balance <= 0 {
+			continue
+		}
+
+		fee, err := money.Percent(merchant.Balance, merchant.Commission)
+		if err != nil {
+			return nil, err
+		}
+
+		if fee > 0 {
+			revenue, err = s.revenueAccount()
+			if err != nil {
+				return nil, err
+			}
+			if revenue.Currency != merchant.Currency {
+				return nil, ErrCurrencyMismatch
+			}
+			fees, err = money.Add(fees, fee)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		settlement := types.Settlement{
+			ID:         uuid.New().String(),
+			BatchID:    batch.ID,
+			MerchantID: merchant.ID,
+			Fee:        fee,
+			Net:        merchant.Balance - fee,
+			Currency:   merchant.Currency,
+		}
+		settled := []*types.Payment{}
+		for _, payment := range s.payments {
+			if payment.MerchantID != merchant.ID || payment.SettlementID != "" || payment.Status == types.PaymentStatusFail {
+				continue
+			}
+			settled = append(settled, payment)
+			settlement.Payments++
+			settlement.Gross += payment.Amount
+		}
+		settlement.Refunded = settlement.Gross - merchant.Balance
+
+		merchants = append(merchants, merchant)
+		payments = append(payments, settled)
+		batch.Settlements = append(batch.Settlements, settlement)
+	}
+
+	if len(batch.Settlements) == 0 {
+		return nil, ErrNothingToSettle
+	}
+	if revenue != nil {
+		balance, err := money.Add(revenue.Balance, fees)
+		if err != nil {
+			return nil, err
+		}
+		revenue.Balance = balance
+	}
+
+	for i, settlement := range batch.Settlements {
+		for _, payment := range payments[i] {
+			payment.SettlementID = settlement.ID
+		}
+		merchants[i].Balance = 0
+	}
+	s.payouts = append(s.payouts, batch)
+	return batch, nil
+}
+
+func (s *Service) FindPayoutByID(batchID string) (*types.PayoutBatch, error) {
+	for _, batch := range s.payouts {
+		if batch.ID == batchID {
+			return batch, nil
+		}
+	}
+	return nil, ErrPayoutNotFound
+}
+
+// Payouts returns all payout batches in creation order
+func (s *Service) Payouts() []types.PayoutBatch {
+	payouts := []types.PayoutBatch{}
+	for _, batch := range s.payouts {
+		payouts = append(payouts, *batch)
+	}
+	return payouts
+}
+
+// SettlementReport writes payout batch settlements to file, one
+// merchantID;name;payments;gross;refunded;fee;net;currency row per merchant
+func (s *Service) SettlementReport(batchID string, path string) (err error) {
+	batch, err := s.FindPayoutByID(batchID)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil {
+			if err == nil {
+				err = cerr
+			}
+			log.Print(cerr)
+		}
+	}()
+
+	fileStr := ""
+	for _, settlement := range batch.Settlements {
+		name := ""
+		merchant, err := s.FindMerchantByID(settlement.MerchantID)
+		if err == nil {
+			name = merchant.Name
+		}
+		fileStr += fmt.Sprint(settlement.MerchantID) + ";" + escapeField(name) + ";" + fmt.Sprint(settlement.Payments) + ";" + fmt.Sprint(settlement.Gross) + ";" + fmt.Sprint(settlement.Refunded) + ";" + fmt.Sprint(settlement.Fee) + ";" + fmt.Sprint(settlement.Net) + ";" + string(settlement.Currency) + "\n"
+	}
+	_, err = file.WriteString(fileStr)
+	return err
+}
@@ -0,0 +1,214 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_Settle_success(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	revenue, err := s.addRevenueAccount()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	merchant, err := s.RegisterMerchant("Pharmacy", "pharmacy", "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetMerchantCommission(merchant.ID, 100)
+	if err != nil {
+		t.Errorf("SetMerchantCommission(): error = %v", err)
+		return
+	}
+
+	first, err := s.PayMerchant(account.ID, merchant.ID, 600_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	second, err := s.PayMerchant(account.ID, merchant.ID, 400_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.Refund(second.ID, 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	batch, err := s.Settle()
+	if err != nil {
+		t.Errorf("Settle(): error = %v", err)
+		return
+	}
+
+	want := types.Settlement{
+		ID:         batch.Settlements[0].ID,
+		BatchID:    batch.ID,
+		MerchantID: merchant.ID,
+		Payments:   2,
+		Gross:      1_000_00,
+		Refunded:   100_00,
+		Fee:        9_00,
+		Net:        891_00,
+		Currency:   types.CurrencyTJS,
+	}
+	if len(batch.Settlements) != 1 || batch.Settlements[0] != want {
+		t.Errorf("Settle(): wrong settlements = %v", batch.Settlements)
+		return
+	}
+	if merchant.Balance != 0 || revenue.Balance != 9_00 || first.SettlementID != want.ID {
+		t.Errorf("Settle(): merchant = %v, revenue = %v, payment = %v", merchant, revenue, first)
+		return
+	}
+
+	_, err = s.Settle()
+	if err != ErrNothingToSettle {
+		t.Errorf("Settle(): must return ErrNothingToSettle, returned = %v", err)
+		return
+	}
+
+	err = s.Reject(first.ID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.PayMerchant(account.ID, merchant.ID, 700_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	batch, err = s.Settle()
+	if err != nil {
+		t.Errorf("Settle(): error = %v", err)
+		return
+	}
+	settlement := batch.Settlements[0]
+	if settlement.Payments != 1 || settlement.Gross != 700_00 || settlement.Refunded != 600_00 || settlement.Net != 99_00 {
+		t.Errorf("Settle(): wrong settlement after reject = %v", settlement)
+		return
+	}
+}
+
+func Test_Settle_fail(t *testing.T) {
+	s := newTestService()
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	free, err := s.RegisterMerchant("Pharmacy", "pharmacy", "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	paid, err := s.RegisterMerchant("Car Shop", "auto", "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.SetMerchantCommission(paid.ID, 100)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	payment, err := s.PayMerchant(account.ID, free.ID, 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.PayMerchant(account.ID, paid.ID, 100_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.Settle()
+	if err != ErrNoRevenueAccount {
+		t.Errorf("Settle(): must return ErrNoRevenueAccount, returned = %v", err)
+		return
+	}
+	if free.Balance != 100_00 || payment.SettlementID != "" || len(s.Payouts()) != 0 {
+		t.Errorf("Settle(): partially applied, merchant = %v, payment = %v", free, payment)
+		return
+	}
+}
+
+func Test_SettlementReport(t *testing.T) {
+	s := newTestService()
+	s.SetClock(&testClock{now: time.Date(2024, 3, 10, 23, 59, 0, 0, time.UTC)})
+	account, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	merchant, err := s.RegisterMerchant("Car; Shop", "auto", "")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	_, err = s.PayMerchant(account.ID, merchant.ID, 250_00)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	batch, err := s.Settle()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settlement.txt")
+	err = s.SettlementReport(batch.ID, path)
+	if err != nil {
+		t.Errorf("SettlementReport(): error = %v", err)
+		return
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil || string(content) != "1;Car%3B+Shop;1;25000;0;0;25000;TJS\n" {
+		t.Errorf("SettlementReport(): content = %q, error = %v", content, err)
+		return
+	}
+
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	payouts := imported.Payouts()
+	if len(payouts) != 1 || payouts[0].ID != batch.ID || !payouts[0].Created.Equal(batch.Created) || !reflect.DeepEqual(payouts[0].Settlements, batch.Settlements) {
+		t.Errorf("Import(): payouts = %v", payouts)
+		return
+	}
+	_, err = imported.Settle()
+	if err != ErrNothingToSettle {
+		t.Errorf("Settle(): must return ErrNothingToSettle, returned = %v", err)
+		return
+	}
+}
@@ -0,0 +1,173 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/fm2901/wallet/pkg/money"
+	"github.com/fm2901/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidSplit = errors.New("invalid split")
+var ErrSplitNotFound = errors.New("split not found")
+
+// SplitPayment pays amount from several accounts divided by shares of kind.
+// Either every share is paid or none, rejecting the split by its ID with
+// Reject reverses every share.
+func (s *Service) SplitPayment(amount types.Money, category types.PaymentCategory, kind types.SplitKind, shares []types.SplitShare) (*types.Split, error) {
+	if amount <= 0 {
+		return nil, ErrAmountmustBePositive
+	}
+
+	parts, err := s.splitParts(amount, kind, shares)
+	if err != nil {
+		return nil, err
+	}
+
+	split := &types.Split{
+		ID:       uuid.New().String(),
+		Amount:   amount,
+		Category: category,
+		Kind:     kind,
+		Status:   types.PaymentStatusInProgress,
+		Created:  s.now(),
+	}
+
+	payments := []*types.Payment{}
+	for i, share := range shares {
+		payment, err := s.Pay(share.AccountID, parts[i], category)
+		if err != nil {
+			for _, paid := range payments {
+				rerr := s.Reject(paid.ID)
+				if rerr != nil {
+					return nil, rerr
+				}
+			}
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	for _, payment := range payments {
+		payment.SplitID = split.ID
+		split.PaymentIDs = append(split.PaymentIDs, payment.ID)
+	}
+	split.Category = payments[0].Category
+	s.splits = append(s.splits, split)
+	return split, nil
+}
+
+func (s *Service) FindSplitByID(splitID string) (*types.Split, error) {
+	for _, split := range s.splits {
+		if split.ID == splitID {
+			return split, nil
+		}
+	}
+	return nil, ErrSplitNotFound
+}
+
+// SplitPayments returns share payments of the split
+func (s *Service) SplitPayments(splitID string) ([]types.Payment, error) {
+	split, err := s.FindSplitByID(splitID)
+	if err != nil {
+		return nil, err
+	}
+
+	payments := []types.Payment{}
+	for _, paymentID := range split.PaymentIDs {
+		payment, err := s.FindPaymentByID(paymentID)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, *payment)
+	}
+	return payments, nil
+}
+
+func (s *Service) splitParts(amount types.Money, kind types.SplitKind, shares []types.SplitShare) ([]types.Money, error) {
+	if len(shares) == 0 {
+		return nil, ErrInvalidSplit
+	}
+
+	var currency types.Currency
+	accounts := map[int64]bool{}
+	for i, share := range shares {
+		account, err := s.FindAccountByID(share.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		if accounts[account.ID] {
+			return nil, ErrInvalidSplit
+		}
+		accounts[account.ID] = true
+		if i > 0 && account.Currency != currency {
+			return nil, ErrCurrencyMismatch
+		}
+		currency = account.Currency
+	}
+
+	var parts []types.Money
+	var err error
+	switch kind {
+	case types.SplitEqual:
+		parts, err = money.Split(amount, len(shares))
+	case types.SplitPercent:
+		percents := []int64{}
+		total := int64(0)
+		for _, share := range shares {
+			percents = append(percents, share.Percent)
+			total += share.Percent
+		}
+		if total != 10_000 {
+			return nil, ErrInvalidSplit
+		}
+		parts, err = money.Allocate(amount, percents)
+	case types.SplitExact:
+		total := types.Money(0)
+		for _, share := range shares {
+			parts = append(parts, share.Amount)
+			total, err = money.Add(total, share.Amount)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if total != amount {
+			return nil, ErrInvalidSplit
+		}
+	default:
+		return nil, ErrInvalidSplit
+	}
+	if err != nil {
+		return nil, ErrInvalidSplit
+	}
+
+	for _, part := range parts {
+		if part <= 0 {
+			return nil, ErrInvalidSplit
+		}
+	}
+	return parts, nil
+}
+
+func (s *Service) rejectSplit(split *types.Split) error {
+	if split.Status == types.PaymentStatusFail {
+		return ErrPaymentRejected
+	}
+
+	for _, paymentID := range split.PaymentIDs {
+		payment, err := s.FindPaymentByID(paymentID)
+		if err != nil {
+			return err
+		}
+		if payment.Status == types.PaymentStatusFail {
+			continue
+		}
+		err = s.Reject(payment.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	split.Status = types.PaymentStatusFail
+	return nil
+}
@@ -0,0 +1,143 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_SplitPayment_success(t *testing.T) {
+	s := newTestService()
+	accounts, err := s.addBatchAccounts(3)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := []struct {
+		kind   types.SplitKind
+		shares []types.SplitShare
+		want   []types.Money
+	}{
+		{
+			kind:   types.SplitEqual,
+			shares: []types.SplitShare{{AccountID: accounts[0].ID}, {AccountID: accounts[1].ID}, {AccountID: accounts[2].ID}},
+			want:   []types.Money{33_34, 33_33, 33_33},
+		},
+		{
+			kind:   types.SplitPercent,
+			shares: []types.SplitShare{{AccountID: accounts[0].ID, Percent: 7_000}, {AccountID: accounts[1].ID, Percent: 3_000}},
+			want:   []types.Money{70_00, 30_00},
+		},
+		{
+			kind:   types.SplitExact,
+			shares: []types.SplitShare{{AccountID: accounts[1].ID, Amount: 10_00}, {AccountID: accounts[2].ID, Amount: 90_00}},
+			want:   []types.Money{10_00, 90_00},
+		},
+	}
+	for _, tt := range tests {
+		split, err := s.SplitPayment(100_00, "food", tt.kind, tt.shares)
+		if err != nil {
+			t.Errorf("SplitPayment(%v): error = %v", tt.kind, err)
+			return
+		}
+
+		payments, err := s.SplitPayments(split.ID)
+		if err != nil || len(payments) != len(tt.want) {
+			t.Errorf("SplitPayments(): payments = %v, error = %v", payments, err)
+			return
+		}
+		for i, payment := range payments {
+			if payment.Amount != tt.want[i] || payment.AccountID != tt.shares[i].AccountID || payment.SplitID != split.ID {
+				t.Errorf("SplitPayment(%v): wrong payment = %v", tt.kind, payment)
+				return
+			}
+		}
+	}
+}
+
+func Test_SplitPayment_fail(t *testing.T) {
+	s := newTestService()
+	accounts, err := s.addBatchAccounts(2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.SplitPayment(100_00, "food", types.SplitPercent, []types.SplitShare{{AccountID: accounts[0].ID, Percent: 5_000}, {AccountID: accounts[1].ID, Percent: 4_000}})
+	if err != ErrInvalidSplit {
+		t.Errorf("SplitPayment(): must return ErrInvalidSplit, returned = %v", err)
+		return
+	}
+
+	_, err = s.SplitPayment(1_500_00, "food", types.SplitExact, []types.SplitShare{{AccountID: accounts[0].ID, Amount: 500_00}, {AccountID: accounts[1].ID, Amount: 1_000_01}})
+	if err != ErrInvalidSplit {
+		t.Errorf("SplitPayment(): must return ErrInvalidSplit, returned = %v", err)
+		return
+	}
+
+	_, err = s.SplitPayment(1_500_01, "food", types.SplitExact, []types.SplitShare{{AccountID: accounts[0].ID, Amount: 500_00}, {AccountID: accounts[1].ID, Amount: 1_000_01}})
+	if err != ErrNotEnoughBalance {
+		t.Errorf("SplitPayment(): must return ErrNotEnoughBalance, returned = %v", err)
+		return
+	}
+	if accounts[0].Balance != 1_000_00 || accounts[1].Balance != 1_000_00 {
+		t.Errorf("SplitPayment(): shares not rolled back = %v, %v", accounts[0], accounts[1])
+		return
+	}
+}
+
+func Test_Reject_split(t *testing.T) {
+	s := newTestService()
+	accounts, err := s.addBatchAccounts(2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	split, err := s.SplitPayment(300_00, "food", types.SplitEqual, []types.SplitShare{{AccountID: accounts[0].ID}, {AccountID: accounts[1].ID}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Reject(split.PaymentIDs[0])
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Reject(split.ID)
+	if err != nil {
+		t.Errorf("Reject(): error = %v", err)
+		return
+	}
+	if split.Status != types.PaymentStatusFail || accounts[0].Balance != 1_000_00 || accounts[1].Balance != 1_000_00 {
+		t.Errorf("Reject(): split = %v, balances = %v, %v", split, accounts[0].Balance, accounts[1].Balance)
+		return
+	}
+
+	err = s.Reject(split.ID)
+	if err != ErrPaymentRejected {
+		t.Errorf("Reject(): must return ErrPaymentRejected, returned = %v", err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+	payments, err := imported.SplitPayments(split.ID)
+	if err != nil || len(payments) != 2 || payments[1].SplitID != split.ID {
+		t.Errorf("Import(): split payments = %v, error = %v", payments, err)
+		return
+	}
+}
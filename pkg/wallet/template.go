@@ -0,0 +1,137 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+var ErrInvalidTemplate = errors.New("invalid template")
+var ErrAmountOutOfRange = errors.New("amount is out of template range")
+var ErrMissingField = errors.New("required field is missing")
+var ErrUnknownField = errors.New("unknown field")
+var ErrInvalidField = errors.New("field value does not match pattern")
+
+// FieldError template field validation error, it matches ErrMissingField,
+// ErrUnknownField or ErrInvalidField with errors.Is
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %q", e.Err, e.Field)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// SetFavoriteTemplate turns favorite into a template with variable amount in
+// [minAmount, maxAmount] and fields. Zero min and max keep the amount fixed,
+// otherwise the favorite amount must be within the range.
+func (s *Service) SetFavoriteTemplate(favoriteID string, minAmount types.Money, maxAmount types.Money, fields []types.TemplateField) error {
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return err
+	}
+
+	if minAmount < 0 || maxAmount < 0 || minAmount > maxAmount {
+		return ErrInvalidTemplate
+	}
+	if maxAmount > 0 && (favorite.Amount < minAmount || favorite.Amount > maxAmount) {
+		return ErrInvalidTemplate
+	}
+
+	names := map[string]bool{}
+	for _, field := range fields {
+		if strings.TrimSpace(field.Name) == "" || names[field.Name] {
+			return ErrInvalidTemplate
+		}
+		names[field.Name] = true
+		_, err := regexp.Compile(field.Pattern)
+		if err != nil {
+			return ErrInvalidTemplate
+		}
+	}
+
+	favorite.MinAmount = minAmount
+	favorite.MaxAmount = maxAmount
+	favorite.Fields = append([]types.TemplateField{}, fields...)
+	return nil
+}
+
+// PayFromTemplate pays from favorite with supplied amount and field values,
+// zero amount means the favorite amount. The values are saved in payment
+// metadata.
+func (s *Service) PayFromTemplate(favoriteID string, amount types.Money, values map[string]string) (*types.Payment, error) {
+	favorite, err := s.FindFavoriteByID(favoriteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount == 0 {
+		amount = favorite.Amount
+	}
+	if favorite.MaxAmount == 0 && amount != favorite.Amount {
+		return nil, ErrAmountOutOfRange
+	}
+	if favorite.MaxAmount > 0 && (amount < favorite.MinAmount || amount > favorite.MaxAmount) {
+		return nil, ErrAmountOutOfRange
+	}
+
+	metadata, err := templateValues(favorite.Fields, values)
+	if err != nil {
+		return nil, err
+	}
+
+	var payment *types.Payment
+	if favorite.MerchantID != 0 {
+		payment, err = s.PayMerchant(favorite.AccountID, favorite.MerchantID, amount)
+	} else {
+		payment, err = s.Pay(favorite.AccountID, amount, favorite.Category)
+	}
+	if err != nil {
+		return nil, err
+	}
+	payment.Metadata = metadata
+	return payment, nil
+}
+
+func templateValues(fields []types.TemplateField, values map[string]string) (map[string]string, error) {
+	known := map[string]bool{}
+	for _, field := range fields {
+		known[field.Name] = true
+	}
+	for name := range values {
+		if !known[name] {
+			return nil, &FieldError{Field: name, Err: ErrUnknownField}
+		}
+	}
+
+	metadata := map[string]string{}
+	for _, field := range fields {
+		value := strings.TrimSpace(values[field.Name])
+		if value == "" {
+			if field.Required {
+				return nil, &FieldError{Field: field.Name, Err: ErrMissingField}
+			}
+			continue
+		}
+		if field.Pattern != "" {
+			matched, err := regexp.MatchString("^(?:"+field.Pattern+")$", value)
+			if err != nil || !matched {
+				return nil, &FieldError{Field: field.Name, Err: ErrInvalidField}
+			}
+		}
+		metadata[field.Name] = value
+	}
+
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	return metadata, nil
+}
@@ -0,0 +1,139 @@
+package wallet
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+var testTemplateFields = []types.TemplateField{
+	{Name: "contract", Required: true, Pattern: `[0-9]{6}`},
+	{Name: "comment"},
+}
+
+func Test_PayFromTemplate_success(t *testing.T) {
+	s := newTestService()
+	_, favorites, err := s.addFavorites("internet")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetFavoriteTemplate(favorites[0].ID, 10_00, 5_000_00, testTemplateFields)
+	if err != nil {
+		t.Errorf("SetFavoriteTemplate(): error = %v", err)
+		return
+	}
+
+	payment, err := s.PayFromTemplate(favorites[0].ID, 250_00, map[string]string{"contract": " 123456 "})
+	if err != nil {
+		t.Errorf("PayFromTemplate(): error = %v", err)
+		return
+	}
+
+	if payment.Amount != 250_00 || !reflect.DeepEqual(payment.Metadata, map[string]string{"contract": "123456"}) {
+		t.Errorf("PayFromTemplate(): wrong payment = %v", payment)
+		return
+	}
+}
+
+func Test_PayFromTemplate_fail(t *testing.T) {
+	s := newTestService()
+	_, favorites, err := s.addFavorites("internet")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetFavoriteTemplate(favorites[0].ID, 500_00, 10_00, nil)
+	if err != ErrInvalidTemplate {
+		t.Errorf("SetFavoriteTemplate(): must return ErrInvalidTemplate, returned = %v", err)
+		return
+	}
+
+	err = s.SetFavoriteTemplate(favorites[0].ID, 10_00, 5_000_00, testTemplateFields)
+	if err != nil {
+		t.Errorf("SetFavoriteTemplate(): error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		amount types.Money
+		values map[string]string
+		err    error
+	}{
+		{amount: 5_001_00, values: map[string]string{"contract": "123456"}, err: ErrAmountOutOfRange},
+		{amount: 100_00, values: map[string]string{"comment": "hi"}, err: ErrMissingField},
+		{amount: 100_00, values: map[string]string{"contract": "12345x"}, err: ErrInvalidField},
+		{amount: 100_00, values: map[string]string{"contract": "123456", "extra": "1"}, err: ErrUnknownField},
+	}
+	for _, tt := range tests {
+		_, err = s.PayFromTemplate(favorites[0].ID, tt.amount, tt.values)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("PayFromTemplate(): must return %v, returned = %v", tt.err, err)
+			return
+		}
+	}
+
+	_, err = s.PayFromFavorite(favorites[0].ID)
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Field != "contract" {
+		t.Errorf("PayFromFavorite(): must return FieldError, returned = %v", err)
+		return
+	}
+
+	err = s.SetFavoriteAmount(favorites[0].ID, 5_001_00)
+	if err != ErrAmountOutOfRange {
+		t.Errorf("SetFavoriteAmount(): must return ErrAmountOutOfRange, returned = %v", err)
+		return
+	}
+}
+
+func Test_Import_templates(t *testing.T) {
+	s := newTestService()
+	account, favorites, err := s.addFavorites("internet")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.SetFavoriteTemplate(favorites[0].ID, 10_00, 5_000_00, testTemplateFields)
+	if err != nil {
+		t.Errorf("SetFavoriteTemplate(): error = %v", err)
+		return
+	}
+
+	payment, err := s.PayFromTemplate(favorites[0].ID, 250_00, map[string]string{"contract": "123456", "comment": "a;b&c=d\n"})
+	if err != nil {
+		t.Errorf("PayFromTemplate(): error = %v", err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+
+	list, err := imported.Favorites(account.ID)
+	if err != nil || !reflect.DeepEqual(list, []types.Favorite{*favorites[0]}) {
+		t.Errorf("Import(): wrong favorites = %v, error = %v", list, err)
+		return
+	}
+
+	got, err := imported.FindPaymentByID(payment.ID)
+	if err != nil || !reflect.DeepEqual(got.Metadata, payment.Metadata) {
+		t.Errorf("Import(): wrong payment = %v, error = %v", got, err)
+		return
+	}
+}
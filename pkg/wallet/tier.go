@@ -0,0 +1,118 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+var ErrUnknownTier = errors.New("unknown verification tier")
+var ErrTierDowngrade = errors.New("tier can only be upgraded")
+
+// DefaultTierLimits returns recommended tier limits, install them with
+// SetTierLimits. Tiers without limits set are not limited.
+func DefaultTierLimits() map[types.Tier]types.TierLimits {
+	return map[types.Tier]types.TierLimits{
+		types.TierAnonymous: {MaxBalance: 3_000_00, MonthlyTurnover: 10_000_00},
+		types.TierBasic:     {MaxBalance: 50_000_00, MonthlyTurnover: 200_000_00},
+		types.TierFull:      {},
+	}
+}
+
+var tierRanks = map[types.Tier]int{
+	types.TierAnonymous: 0,
+	types.TierBasic:     1,
+	types.TierFull:      2,
+}
+
+// SetTierLimits sets limits of the tier
+func (s *Service) SetTierLimits(tier types.Tier, limits types.TierLimits) error {
+	if _, ok := tierRanks[tier]; !ok {
+		return ErrUnknownTier
+	}
+	if limits.MaxBalance < 0 || limits.MonthlyTurnover < 0 {
+		return ErrAmountmustBePositive
+	}
+
+	if s.tierLimits == nil {
+		s.tierLimits = map[types.Tier]types.TierLimits{}
+	}
+	s.tierLimits[tier] = limits
+	return nil
+}
+
+// TierLimits returns limits applied to the tier, zero limits mean none
+func (s *Service) TierLimits(tier types.Tier) types.TierLimits {
+	return s.tierLimits[tier]
+}
+
+// UpgradeTier raises account verification tier and records the change
+func (s *Service) UpgradeTier(accountID int64, tier types.Tier, reason string) error {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+
+	rank, ok := tierRanks[tier]
+	if !ok {
+		return ErrUnknownTier
+	}
+	if rank <= tierRanks[account.Tier] {
+		return ErrTierDowngrade
+	}
+
+	s.tierChanges = append(s.tierChanges, &types.TierChange{
+		AccountID: account.ID,
+		From:      account.Tier,
+		To:        tier,
+		Reason:    reason,
+		Changed:   s.now(),
+	})
+	account.Tier = tier
+	return nil
+}
+
+// TierHistory returns audit trail of account tier changes
+func (s *Service) TierHistory(accountID int64) ([]types.TierChange, error) {
+	account, err := s.FindAccountByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := []types.TierChange{}
+	for _, change := range s.tierChanges {
+		if change.AccountID == account.ID {
+			history = append(history, *change)
+		}
+	}
+	return history, nil
+}
+
+func (s *Service) checkTierBalance(account *types.Account, amount types.Money) error {
+	limits := s.TierLimits(account.Tier)
+	if limits.MaxBalance > 0 && account.Balance+amount > limits.MaxBalance {
+		return &LimitError{Kind: LimitTierBalance, Limit: limits.MaxBalance, Remaining: remaining(limits.MaxBalance, account.Balance)}
+	}
+	return nil
+}
+
+func (s *Service) checkTierTurnover(account *types.Account, amount types.Money) error {
+	limits := s.TierLimits(account.Tier)
+	if limits.MonthlyTurnover <= 0 {
+		return nil
+	}
+
+	now := s.now()
+	monthStart := startOfMonth(now)
+	turnover := s.spent(account.ID, monthStart, "")
+	for _, transfer := range s.transfers {
+		if transfer.FromAccountID == account.ID && !transfer.Created.Before(monthStart) {
+			turnover += transfer.Amount
+		}
+	}
+
+	if turnover+amount > limits.MonthlyTurnover {
+		return &LimitError{Kind: LimitTierTurnover, Limit: limits.MonthlyTurnover, Remaining: remaining(limits.MonthlyTurnover, turnover)}
+	}
+	return nil
+}
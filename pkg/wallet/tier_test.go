@@ -0,0 +1,173 @@
+package wallet
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_Deposit_tierBalance(t *testing.T) {
+	s := newTestService()
+	account, err := s.RegisterAccount("992000000001")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if account.Tier != types.TierAnonymous {
+		t.Errorf("RegisterAccount(): wrong tier, account = %v", account)
+		return
+	}
+
+	err = s.Deposit(account.ID, 5_000_00)
+	if err != nil {
+		t.Errorf("Deposit(): tier limited before SetTierLimits, error = %v", err)
+		return
+	}
+
+	for tier, limits := range DefaultTierLimits() {
+		err = s.SetTierLimits(tier, limits)
+		if err != nil {
+			t.Errorf("SetTierLimits(): error = %v", err)
+			return
+		}
+	}
+
+	account, err = s.RegisterAccount("992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	err = s.Deposit(account.ID, 3_000_01)
+	limitErr := &LimitError{}
+	if !errors.As(err, &limitErr) || limitErr.Kind != LimitTierBalance {
+		t.Errorf("Deposit(): wrong limit error = %v", err)
+		return
+	}
+	if !errors.Is(err, ErrTierLimitExceeded) || errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Deposit(): must return ErrTierLimitExceeded, returned = %v", err)
+		return
+	}
+
+	err = s.UpgradeTier(account.ID, types.TierBasic, "passport checked")
+	if err != nil {
+		t.Errorf("UpgradeTier(): error = %v", err)
+		return
+	}
+
+	err = s.Deposit(account.ID, 3_000_01)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+}
+
+func Test_Pay_tierTurnover(t *testing.T) {
+	s := newTestService()
+	err := s.SetTierLimits(types.TierAnonymous, types.TierLimits{MaxBalance: 3_000_00, MonthlyTurnover: 2_000_00})
+	if err != nil {
+		t.Errorf("SetTierLimits(): error = %v", err)
+		return
+	}
+
+	account, err := s.RegisterAccount("992000000001")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	other, err := s.RegisterAccount("992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.Deposit(account.ID, 3_000_00)
+	if err != nil {
+		t.Errorf("Deposit(): error = %v", err)
+		return
+	}
+
+	_, err = s.Pay(account.ID, 1_500_00, "auto")
+	if err != nil {
+		t.Errorf("Pay(): error = %v", err)
+		return
+	}
+
+	_, err = s.Transfer(account.ID, other.ID, 600_00)
+	limitErr := &LimitError{}
+	if !errors.As(err, &limitErr) || limitErr.Kind != LimitTierTurnover || limitErr.Remaining != 500_00 {
+		t.Errorf("Transfer(): wrong limit error = %v", err)
+		return
+	}
+}
+
+func Test_UpgradeTier_history(t *testing.T) {
+	s := newTestService()
+	account, err := s.RegisterAccount("992000000001")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = s.UpgradeTier(account.ID, types.TierFull, "video; in office")
+	if err != nil {
+		t.Errorf("UpgradeTier(): error = %v", err)
+		return
+	}
+
+	err = s.UpgradeTier(account.ID, types.TierBasic, "")
+	if err != ErrTierDowngrade {
+		t.Errorf("UpgradeTier(): must return ErrTierDowngrade, returned = %v", err)
+		return
+	}
+
+	dir := t.TempDir()
+	err = s.Export(dir)
+	if err != nil {
+		t.Errorf("Export(): error = %v", err)
+		return
+	}
+
+	imported := newTestService()
+	err = imported.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+
+	history, err := imported.TierHistory(account.ID)
+	if err != nil {
+		t.Errorf("TierHistory(): error = %v", err)
+		return
+	}
+
+	if len(history) != 1 || history[0].From != types.TierAnonymous || history[0].To != types.TierFull || history[0].Reason != "video; in office" {
+		t.Errorf("TierHistory(): wrong history = %v", history)
+		return
+	}
+}
+
+func Test_Import_legacyAccountLimits(t *testing.T) {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "accounts.dump"), []byte("1;992000000001;1000000000000"), 0666)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	s := newTestService()
+	err = s.Import(dir)
+	if err != nil {
+		t.Errorf("Import(): error = %v", err)
+		return
+	}
+
+	err = s.Deposit(1, 100_00)
+	if err != nil {
+		t.Errorf("Deposit(): imported account is limited, error = %v", err)
+		return
+	}
+}
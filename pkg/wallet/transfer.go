@@ -0,0 +1,73 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/fm2901/wallet/pkg/money"
+	"github.com/fm2901/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrSameAccount = errors.New("transfer to the same account")
+
+// Transfer moves money between two active accounts in the same currency
+func (s *Service) Transfer(fromAccountID int64, toAccountID int64, amount types.Money) (*types.Transfer, error) {
+	if amount <= 0 {
+		return nil, ErrAmountmustBePositive
+	}
+	if fromAccountID == toAccountID {
+		return nil, ErrSameAccount
+	}
+
+	from, err := s.FindAccountByID(fromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.FindAccountByID(toAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkAccountActive(from)
+	if err != nil {
+		return nil, err
+	}
+	err = checkAccountActive(to)
+	if err != nil {
+		return nil, err
+	}
+	if from.Currency != to.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	err = s.checkTierTurnover(from, amount)
+	if err != nil {
+		return nil, err
+	}
+	err = s.checkTierBalance(to, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ExpireHolds()
+	if from.Available() < amount {
+		return nil, ErrNotEnoughBalance
+	}
+
+	balance, err := money.Add(to.Balance, amount)
+	if err != nil {
+		return nil, err
+	}
+	from.Balance -= amount
+	to.Balance = balance
+
+	transfer := &types.Transfer{
+		ID:            uuid.New().String(),
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		Created:       s.now(),
+	}
+	s.transfers = append(s.transfers, transfer)
+	return transfer, nil
+}
@@ -0,0 +1,73 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/fm2901/wallet/pkg/types"
+)
+
+func Test_Transfer_success(t *testing.T) {
+	s := newTestService()
+	from, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	to, err := s.RegisterAccount("992000000002")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	balance := from.Balance
+	transfer, err := s.Transfer(from.ID, to.ID, 500_00)
+	if err != nil {
+		t.Errorf("Transfer(): error = %v", err)
+		return
+	}
+
+	if from.Balance != balance-500_00 || to.Balance != 500_00 || transfer.Amount != 500_00 {
+		t.Errorf("Transfer(): wrong balances, from = %v, to = %v", from, to)
+		return
+	}
+}
+
+func Test_Transfer_fail(t *testing.T) {
+	s := newTestService()
+	from, _, err := s.addAccount(defaultTestAccount)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	to, err := s.RegisterAccountWithCurrency("992000000002", types.CurrencyUSD)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.Transfer(from.ID, to.ID, 500_00)
+	if err != ErrCurrencyMismatch {
+		t.Errorf("Transfer(): must return ErrCurrencyMismatch, returned = %v", err)
+		return
+	}
+
+	_, err = s.Transfer(from.ID, from.ID, 500_00)
+	if err != ErrSameAccount {
+		t.Errorf("Transfer(): must return ErrSameAccount, returned = %v", err)
+		return
+	}
+
+	empty, err := s.RegisterAccount("992000000003")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = s.Transfer(empty.ID, from.ID, 500_00)
+	if err != ErrNotEnoughBalance {
+		t.Errorf("Transfer(): must return ErrNotEnoughBalance, returned = %v", err)
+		return
+	}
+}